@@ -0,0 +1,107 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"reflect"
+	"sort"
+)
+
+// convertChain finds the shortest chain of registered converters which
+// converts src to dst, e.g. A->B and B->C for A->C, and composes them into
+// a converter. The result is cached in the porter, including the miss.
+func (m *porter) convertChain(dst, src reflect.Type) (reflect.Value, bool) {
+	key := pair{dst, src}
+	if cached, ok := m.chains.Load(key); ok {
+		chain := cached.(reflect.Value)
+		return chain, chain.IsValid()
+	}
+	chain := reflect.Value{}
+	if steps := m.shortestChain(dst, src); len(steps) > 0 {
+		chain = m.composeChain(steps)
+	}
+	m.chains.Store(key, chain)
+	return chain, chain.IsValid()
+}
+
+// shortestChain searches the converter graph in breadth-first order, the types
+// already visited are skipped, so cycles like A->B->A are never followed.
+func (m *porter) shortestChain(dst, src reflect.Type) []pair {
+	if len(m.convertFuncs) < 2 {
+		return nil
+	}
+	// sort the edges to choose the same chain between the paths with the same length
+	edges := make([]pair, 0, len(m.convertFuncs))
+	for k := range m.convertFuncs {
+		if !m.stdPairs[k] {
+			edges = append(edges, k)
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Src.String() != edges[j].Src.String() {
+			return edges[i].Src.String() < edges[j].Src.String()
+		}
+		return edges[i].Dst.String() < edges[j].Dst.String()
+	})
+
+	// from records the edge through which the type is reached
+	from := map[reflect.Type]pair{}
+	visited := map[reflect.Type]bool{src: true}
+	queue := []reflect.Type{src}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		for _, edge := range edges {
+			if edge.Src != t || visited[edge.Dst] {
+				continue
+			}
+			visited[edge.Dst] = true
+			from[edge.Dst] = edge
+			if edge.Dst != dst {
+				queue = append(queue, edge.Dst)
+				continue
+			}
+			// walk back to src
+			steps := []pair{}
+			for cur := dst; cur != src; cur = from[cur].Src {
+				steps = append([]pair{from[cur]}, steps...)
+			}
+			return steps
+		}
+	}
+	return nil
+}
+
+// composeChain composes the converters of steps into one converter, the
+// intermediate values are converted from the zero value of their types.
+func (m *porter) composeChain(steps []pair) reflect.Value {
+	return reflect.ValueOf(func(dst, src interface{}, o *Options) (interface{}, error) {
+		cur := reflect.ValueOf(src)
+		for i, step := range steps {
+			target := reflect.New(step.Dst).Elem()
+			if i == len(steps)-1 {
+				target = reflect.ValueOf(dst)
+			}
+			converted, err := m.callCustom(m.convertFuncs[step], target, cur, o)
+			if err != nil {
+				return dst, err
+			}
+			cur = converted
+		}
+		return cur.Interface(), nil
+	})
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+var _ = Describe("converter chain", func() {
+	type v1 struct {
+		Name string
+	}
+	type v2 struct {
+		DisplayName string
+	}
+	type v3 struct {
+		Title string
+	}
+
+	var calls int
+	v1ToV2 := func(dst v2, src v1, o *Options) (v2, error) {
+		calls++
+		return v2{DisplayName: src.Name}, nil
+	}
+	v2ToV3 := func(dst v3, src v2, o *Options) (v3, error) {
+		calls++
+		return v3{Title: src.DisplayName}, nil
+	}
+	v2ToV1 := func(dst v1, src v2, o *Options) (v1, error) {
+		return v1{Name: src.DisplayName}, nil
+	}
+	v3ToV2 := func(dst v2, src v3, o *Options) (v2, error) {
+		return v2{DisplayName: src.Title}, nil
+	}
+
+	BeforeEach(func() {
+		calls = 0
+	})
+
+	It("converts through the intermediate type", func() {
+		dst := v3{}
+		err := Merge(&dst, v1{Name: "foo"}, WithConverters(v1ToV2, v2ToV3))
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(v3{Title: "foo"}))
+		Expect(calls).To(Equal(2))
+	})
+
+	It("is not confused by cycles", func() {
+		err := p.addCustomFuncs(v1ToV2, v2ToV1, v2ToV3, v3ToV2)
+		Expect(err).To(BeNil())
+		chain, ok := p.convertChain(reflect.TypeOf(v1{}), reflect.TypeOf(v3{}))
+		Expect(ok).To(BeTrue())
+		Expect(chain.IsValid()).To(BeTrue())
+
+		_, ok = p.convertChain(reflect.TypeOf(v1{}), reflect.TypeOf(""))
+		Expect(ok).NotTo(BeTrue())
+	})
+
+	It("uses the shortest chain", func() {
+		err := p.addCustomFuncs(
+			func(dst string, src int, o *Options) (string, error) { return strconv.Itoa(src), nil },
+			func(dst v1, src string, o *Options) (v1, error) { return v1{Name: src}, nil },
+			func(dst float64, src int, o *Options) (float64, error) { return float64(src), nil },
+			func(dst bool, src float64, o *Options) (bool, error) { return src != 0, nil },
+			func(dst string, src bool, o *Options) (string, error) { return "bool", nil },
+		)
+		Expect(err).To(BeNil())
+		steps := p.shortestChain(reflect.TypeOf(v1{}), reflect.TypeOf(0))
+		Expect(fmt.Sprint(steps)).To(Equal(fmt.Sprint([]pair{
+			{Dst: reflect.TypeOf(""), Src: reflect.TypeOf(0)},
+			{Dst: reflect.TypeOf(v1{}), Src: reflect.TypeOf("")},
+		})))
+	})
+
+	It("caches the chain until the converters change", func() {
+		err := p.addCustomFuncs(v1ToV2)
+		Expect(err).To(BeNil())
+		_, ok := p.convertChain(reflect.TypeOf(v3{}), reflect.TypeOf(v1{}))
+		Expect(ok).NotTo(BeTrue())
+		_, cached := p.chains.Load(pair{reflect.TypeOf(v3{}), reflect.TypeOf(v1{})})
+		Expect(cached).To(BeTrue())
+
+		err = p.addCustomFuncs(v2ToV3)
+		Expect(err).To(BeNil())
+		_, ok = p.convertChain(reflect.TypeOf(v3{}), reflect.TypeOf(v1{}))
+		Expect(ok).To(BeTrue())
+	})
+
+	It("returns the error of the step", func() {
+		failed := func(dst v3, src v2, o *Options) (v3, error) {
+			return dst, fmt.Errorf("failed")
+		}
+		dst := v3{}
+		err := Merge(&dst, v1{Name: "foo"}, WithConverters(v1ToV2, failed))
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,115 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"reflect"
+	"strings"
+)
+
+// convertSlice converts the elements of src slice or array to the element type
+// of dst one by one. If dst is a slice, the converted slice is merged into dst
+// following the SliceMergeMode, if dst is an array, the converted elements are
+// merged into the elements of dst at the same index.
+func (m *porter) convertSlice(dst, src reflect.Value, o *Options) error {
+	if src.Kind() == reflect.Slice && src.IsNil() {
+		return nil
+	}
+	dstType := dst.Type()
+
+	if dstType.Kind() == reflect.Array {
+		converted := reflect.New(dstType).Elem()
+		converted.Set(dst)
+		for i := 0; i < src.Len() && i < dstType.Len(); i++ {
+			eo := o.at(dst, indexSegment(i))
+			if err := m.defaultMerge(converted.Index(i), src.Index(i), eo); err != nil {
+				return eo.wrapError(err)
+			}
+		}
+		if dst.CanSet() {
+			dst.Set(converted)
+		}
+		return nil
+	}
+
+	converted := reflect.MakeSlice(dstType, src.Len(), src.Len())
+	for i := 0; i < src.Len(); i++ {
+		eo := o.at(dst, indexSegment(i))
+		if err := m.defaultMerge(converted.Index(i), src.Index(i), eo); err != nil {
+			return eo.wrapError(err)
+		}
+	}
+	return m.deepMerge(dst, converted, o)
+}
+
+// convertMap converts the keys and values of src map to the key and value
+// types of dst, and then merges the converted map into dst.
+func (m *porter) convertMap(dst, src reflect.Value, o *Options) error {
+	if src.IsNil() {
+		return nil
+	}
+	dstType := dst.Type()
+	converted := reflect.MakeMapWithSize(dstType, src.Len())
+	for _, key := range src.MapKeys() {
+		eo := o.at(dst, indexSegment(key))
+		k := reflect.New(dstType.Key()).Elem()
+		if err := m.defaultMerge(k, key, eo); err != nil {
+			return eo.wrapError(err)
+		}
+		v := reflect.New(dstType.Elem()).Elem()
+		if err := m.defaultMerge(v, src.MapIndex(key), eo); err != nil {
+			return eo.wrapError(err)
+		}
+		converted.SetMapIndex(k, v)
+	}
+	return m.deepMerge(dst, converted, o)
+}
+
+// joinSlice converts the elements of src slice or array to string, and joins
+// them by o.SliceSeparator into dst string.
+func (m *porter) joinSlice(dst, src reflect.Value, o *Options) error {
+	if src.Kind() == reflect.Slice && src.IsNil() {
+		return nil
+	}
+	elems := make([]string, src.Len())
+	for i := 0; i < src.Len(); i++ {
+		eo := o.at(dst, indexSegment(i))
+		if err := m.defaultMerge(reflect.ValueOf(&elems[i]).Elem(), src.Index(i), eo); err != nil {
+			return eo.wrapError(err)
+		}
+	}
+	joined := reflect.New(dst.Type()).Elem()
+	joined.SetString(strings.Join(elems, o.SliceSeparator))
+	return directMerge(dst, joined, o)
+}
+
+// splitString splits s by sep into []string, the spaces around elements are
+// trimmed, an empty string results in an empty slice.
+func splitString(s, sep string) reflect.Value {
+	elems := []string{}
+	if len(strings.TrimSpace(s)) > 0 {
+		for _, elem := range strings.Split(s, sep) {
+			elems = append(elems, strings.TrimSpace(elem))
+		}
+	}
+	return reflect.ValueOf(elems)
+}
+
+// isBytes reports whether t is a slice of bytes, which is not treated as a list
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+var _ = Describe("convert slice", func() {
+	DescribeTable(
+		"",
+		expectConvertWith,
+		Entry("[]int32 to []int64", []int64{1}, []int32{2, 3}, []int64{2, 3}, nil, false),
+		Entry("[]interface{} to []string", []string(nil), []interface{}{"a", "b"}, []string{"a", "b"}, nil, false),
+		Entry("[]interface{} to []int", []int(nil), []interface{}{1.0, int64(2)}, []int{1, 2}, nil, false),
+		Entry("nil src", []int64{1}, []int32(nil), []int64{1}, nil, false),
+		Entry("append mode", []int64{1}, []int32{2}, []int64{1, 2}, WithSliceMode(AppendSlice), false),
+		Entry("unite mode", []int64{1, 2}, []int32{2, 3}, []int64{1, 2, 3}, WithSliceMode(UniteSlice), false),
+		Entry("without overwrite", []int64{1}, []int32{2}, []int64{1}, WithoutOverwrite, false),
+		Entry("array to slice", []int64(nil), [2]int32{1, 2}, []int64{1, 2}, nil, false),
+		Entry("slice to array", [3]int64{1, 2, 3}, []int32{4, 5}, [3]int64{4, 5, 3}, nil, false),
+		Entry("inconvertible element", []int(nil), []interface{}{"a"}, nil, nil, true),
+	)
+
+	It("decodes JSON values into typed slices", func() {
+		type container struct {
+			Name string `json:"name"`
+			Args []string
+		}
+		type spec struct {
+			Containers []container `json:"containers"`
+		}
+		dst := spec{}
+		src := map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "a", "Args": []interface{}{"1", "2"}},
+				map[string]interface{}{"name": "b"},
+			},
+		}
+		Expect(Merge(&dst, src)).To(BeNil())
+		Expect(dst).To(Equal(spec{
+			Containers: []container{
+				{Name: "a", Args: []string{"1", "2"}},
+				{Name: "b"},
+			},
+		}))
+	})
+
+	It("reports the index of element", func() {
+		dst := []int{}
+		err := Merge(&dst, []interface{}{1, "a"})
+		Expect(err).NotTo(BeNil())
+		Expect(err.(*MergeError).Path).To(Equal("[1]"))
+	})
+})
+
+var _ = Describe("convert map", func() {
+	DescribeTable(
+		"",
+		expectConvertWith,
+		Entry("map[string]interface{} to map[string]int",
+			map[string]int{"a": 1, "b": 1}, map[string]interface{}{"b": 2.0, "c": int32(3)},
+			map[string]int{"a": 1, "b": 2, "c": 3}, nil, false),
+		Entry("map[interface{}]interface{} to map[string]string",
+			map[string]string(nil), map[interface{}]interface{}{"a": "1"},
+			map[string]string{"a": "1"}, nil, false),
+		Entry("nested map",
+			map[string]map[string]int{"a": {"a": 1}}, map[interface{}]interface{}{"a": map[interface{}]interface{}{"b": 2}},
+			map[string]map[string]int{"a": {"a": 1, "b": 2}}, nil, false),
+		Entry("without overwrite",
+			map[string]int{"a": 1}, map[string]int32{"a": 2, "b": 2},
+			map[string]int{"a": 1, "b": 2}, WithoutOverwrite, false),
+		Entry("key with str convertion",
+			map[int]bool{1: true}, map[string]string{"2": "yes"},
+			map[int]bool{1: true, 2: true}, WithStrConvertion, false),
+		Entry("nil src", map[string]int{"a": 1}, map[string]int32(nil), map[string]int{"a": 1}, nil, false),
+		Entry("inconvertible key", map[string]int{}, map[interface{}]interface{}{1: 1}, nil, nil, true),
+		Entry("inconvertible value", map[string]int{}, map[string]interface{}{"a": "a"}, nil, nil, true),
+	)
+})
+
+var _ = Describe("convert scalar and string to slice", func() {
+	type tag string
+
+	DescribeTable(
+		"",
+		expectConvertWith,
+		Entry("scalar is disabled by default", []string(nil), "foo", nil, nil, true),
+		Entry("scalar to slice", []string(nil), "foo", []string{"foo"}, WithScalarToSlice, false),
+		Entry("scalar to converted slice", []int64{1}, int32(2), []int64{2}, WithScalarToSlice, false),
+		Entry("map to slice of struct", []struct{ Name string }(nil), map[string]interface{}{"Name": "foo"},
+			[]struct{ Name string }{{Name: "foo"}}, WithScalarToSlice, false),
+		Entry("split string", []string(nil), "foo, bar", []string{"foo", "bar"}, WithStringSplit(","), false),
+		Entry("split empty string", []string{"foo"}, "", []string{}, WithStringSplit(","), false),
+		Entry("split to named string", []tag(nil), "a;b", []tag{"a", "b"}, WithStringSplit(";"), false),
+		Entry("split to ints", []int(nil), "1,2", []int{1, 2}, func(o *Options) {
+			WithStringSplit(",")(o)
+			WithStrConvertion(o)
+		}, false),
+		Entry("split in append mode", []string{"a"}, "b,c", []string{"a", "b", "c"}, func(o *Options) {
+			WithStringSplit(",")(o)
+			WithSliceMode(AppendSlice)(o)
+		}, false),
+		Entry("split takes precedence", []string(nil), "a,b", []string{"a", "b"}, func(o *Options) {
+			WithStringSplit(",")(o)
+			WithScalarToSlice(o)
+		}, false),
+		Entry("join slice", "", []string{"foo", "bar"}, "foo,bar", WithStringSplit(","), false),
+		Entry("join array to named string", tag(""), [2]tag{"a", "b"}, tag("a b"), WithStringSplit(" "), false),
+		Entry("join ints", "", []int{1, 2}, "1,2", func(o *Options) {
+			WithStringSplit(",")(o)
+			WithStrConvertion(o)
+		}, false),
+		Entry("join without overwrite", "a", []string{"b"}, "a", func(o *Options) {
+			WithStringSplit(",")(o)
+			WithoutOverwrite(o)
+		}, false),
+		Entry("bytes are not split", []byte(nil), "a,b", nil, WithStringSplit(","), true),
+	)
+
+	It("merges config values", func() {
+		type config struct {
+			Tags  []string
+			Hosts []string
+		}
+		dst := config{}
+		err := Merge(&dst, map[string]interface{}{"Tags": "foo", "Hosts": []interface{}{"a", "b"}}, WithScalarToSlice)
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(config{Tags: []string{"foo"}, Hosts: []string{"a", "b"}}))
+	})
+})
@@ -0,0 +1,43 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"reflect"
+)
+
+var mergeContextType = reflect.TypeOf(&MergeContext{})
+
+// MergeContext describes where the value being merged is, it is passed to
+// the custom funcs like
+//
+// func(ctx *MergeContext, dst, src int) (int, error) {}
+type MergeContext struct {
+	// Path is the path of value from the target, e.g. Spec.Containers[0].Env,
+	// it is empty for the target itself
+	Path string
+	// Parent is the struct, map or slice containing the value,
+	// it is invalid for the target itself
+	Parent reflect.Value
+	// Field is the struct field of the value, you can get the tags from it.
+	// It is nil if the value is not a struct field
+	Field *reflect.StructField
+	// Depth is the number of the segments of Path
+	Depth int
+	// Options used to merge the value
+	Options *Options
+}
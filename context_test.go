@@ -0,0 +1,85 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"reflect"
+
+	"github.com/onsi/gomega"
+)
+
+var _ = Describe("merge context", func() {
+	type meta struct {
+		Labels      map[string]string `merge:"replace"`
+		Annotations map[string]string
+	}
+	type object struct {
+		Meta meta
+	}
+
+	It("custom func with context", func() {
+		contexts := map[string]MergeContext{}
+		dst := object{
+			Meta: meta{
+				Labels:      map[string]string{"a": "a"},
+				Annotations: map[string]string{"a": "a"},
+			},
+		}
+		src := object{
+			Meta: meta{
+				Labels:      map[string]string{"b": "b"},
+				Annotations: map[string]string{"b": "b"},
+			},
+		}
+		err := Merge(&dst, src, WithMergeFuncs(func(ctx *MergeContext, dst, src map[string]string) (map[string]string, error) {
+			contexts[ctx.Path] = *ctx
+			if ctx.Field.Tag.Get("merge") == "replace" {
+				return src, nil
+			}
+			for k, v := range src {
+				dst[k] = v
+			}
+			return dst, nil
+		}))
+		Expect(err).To(BeNil())
+		Expect(dst.Meta.Labels).To(Equal(map[string]string{"b": "b"}))
+		Expect(dst.Meta.Annotations).To(Equal(map[string]string{"a": "a", "b": "b"}))
+
+		Expect(contexts).To(gomega.HaveLen(2))
+		ctx := contexts["Meta.Labels"]
+		Expect(ctx.Depth).To(Equal(2))
+		Expect(ctx.Field.Name).To(Equal("Labels"))
+		Expect(ctx.Parent.Type()).To(Equal(reflect.TypeOf(meta{})))
+		Expect(ctx.Options).NotTo(BeNil())
+	})
+
+	It("context of map element", func() {
+		var got *MergeContext
+		dst := map[string]int{"a": 1}
+		src := map[string]int{"a": 2}
+		err := Merge(&dst, src, WithMergeFuncs(func(ctx *MergeContext, dst, src int) (int, error) {
+			got = ctx
+			return dst + src, nil
+		}))
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(map[string]int{"a": 3}))
+		Expect(got.Path).To(Equal("[a]"))
+		Expect(got.Depth).To(Equal(1))
+		Expect(got.Field).To(BeNil())
+		Expect(got.Parent.Kind()).To(Equal(reflect.Map))
+	})
+})
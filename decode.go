@@ -0,0 +1,294 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldTag is the parsed struct tag of a field, e.g.
+//
+//	Memory int64 `json:"memory,omitempty" merge:"unit=bytes"`
+type fieldTag struct {
+	// name is the key of the field in map
+	name string
+	// skip is true if the name is "-"
+	skip bool
+	// options are the options in tags, e.g. omitempty, unit=bytes
+	options []string
+}
+
+// has reports whether the tag has the option
+func (t fieldTag) has(option string) bool {
+	for _, opt := range t.options {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// get returns the value of option like key=value
+func (t fieldTag) get(key string) (string, bool) {
+	for _, opt := range t.options {
+		if strings.HasPrefix(opt, key+"=") {
+			return opt[len(key)+1:], true
+		}
+	}
+	return "", false
+}
+
+// parseFieldTag parses the tags of field in the order of o.TagNames, the name
+// comes from the first tag which has a name, and it is the field name if no tag
+// has a name. The part containing "=" is treated as an option instead of name.
+func parseFieldTag(field reflect.StructField, o *Options) fieldTag {
+	ret := fieldTag{}
+	for _, tagName := range o.TagNames {
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+		if tag == "-" {
+			if len(ret.name) == 0 {
+				ret.skip = true
+				return ret
+			}
+			continue
+		}
+		for i, part := range strings.Split(tag, ",") {
+			if i == 0 && !strings.Contains(part, "=") {
+				if len(ret.name) == 0 {
+					ret.name = part
+				}
+				continue
+			}
+			if len(part) > 0 {
+				ret.options = append(ret.options, part)
+			}
+		}
+	}
+	if len(ret.name) == 0 {
+		ret.name = field.Name
+	}
+	return ret
+}
+
+// mapToStruct merges the map with string keys into struct, the keys match
+// the names of fields which come from tags (see Options.TagNames) or field names.
+// The unknown keys are ignored.
+func (m *porter) mapToStruct(dst, src reflect.Value, o *Options) error {
+	if !hasExportedField(dst.Type()) {
+		// e.g. time.Time, it is a single entity
+		return fmt.Errorf("can not convert %v to %v without exported fields", src.Type(), dst.Type())
+	}
+	src = derefInterface(src)
+	if src.IsNil() {
+		return nil
+	}
+	keys := map[string]reflect.Value{}
+	for _, key := range src.MapKeys() {
+		k := derefInterface(key)
+		if k.Kind() != reflect.String {
+			return fmt.Errorf("can not convert %v with %v key to %v", src.Type(), k.Type(), dst.Type())
+		}
+		keys[k.String()] = src.MapIndex(key)
+	}
+	return m.mapKeysToStruct(dst, keys, o)
+}
+
+func (m *porter) mapKeysToStruct(dst reflect.Value, keys map[string]reflect.Value, o *Options) error {
+	dstType := dst.Type()
+	for i := 0; i < dstType.NumField(); i++ {
+		field := dstType.Field(i)
+		if len(field.PkgPath) > 0 && !field.Anonymous {
+			// unexported
+			continue
+		}
+		tag := parseFieldTag(field, o)
+		if tag.skip {
+			continue
+		}
+		fieldV := dst.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && tag.name == field.Name {
+			// flatten the embedded struct without name in tag, like encoding/json
+			if err := m.mapKeysToStruct(fieldV, keys, o.atField(dst, field)); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		value, ok := lookupKey(keys, tag.name, o)
+		if !ok {
+			continue
+		}
+		fo := o.atField(dst, field)
+		if err := m.defaultMerge(fieldV, value, fo); err != nil {
+			return fo.wrapError(err)
+		}
+	}
+	return nil
+}
+
+func lookupKey(keys map[string]reflect.Value, name string, o *Options) (reflect.Value, bool) {
+	if value, ok := keys[name]; ok {
+		return value, true
+	}
+	if o.CaseInsensitiveKeys {
+		for key, value := range keys {
+			if strings.EqualFold(key, name) {
+				return value, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// structToMap encodes the struct into map[string]interface{} and merges it into
+// dst map, the keys come from tags (see Options.TagNames) or field names.
+func (m *porter) structToMap(dst, src reflect.Value, o *Options) error {
+	if !hasExportedField(src.Type()) {
+		// e.g. time.Time, it is a single entity
+		return fmt.Errorf("can not convert %v without exported fields to %v", src.Type(), dst.Type())
+	}
+	encoded := reflect.ValueOf(encodeStruct(src, o))
+	return m.defaultMerge(dst, encoded, o)
+}
+
+// encodeStruct encodes the struct into map[string]interface{}, the unexported fields
+// are skipped like encoding/json. The nested structs are encoded recursively except
+// the ones without exported fields, e.g. time.Time.
+// The fields with omitempty option in tag are omitted if they are empty,
+// and the nil pointers and unset Optionals are always omitted.
+func encodeStruct(src reflect.Value, o *Options) map[string]interface{} {
+	ret := map[string]interface{}{}
+	encodeStructInto(ret, src, o)
+	return ret
+}
+
+func encodeStructInto(ret map[string]interface{}, src reflect.Value, o *Options) {
+	srcType := src.Type()
+	for i := 0; i < srcType.NumField(); i++ {
+		field := srcType.Field(i)
+		tag := parseFieldTag(field, o)
+		if tag.skip {
+			continue
+		}
+		fieldV := src.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && tag.name == field.Name {
+			// flatten the embedded struct without name in tag, like encoding/json
+			encodeStructInto(ret, fieldV, o)
+			continue
+		}
+		if len(field.PkgPath) > 0 {
+			// unexported
+			continue
+		}
+		if tag.has("omitempty") && o.isEmpty(fieldV) {
+			continue
+		}
+		if value, ok := encodeValue(fieldV, o); ok {
+			ret[tag.name] = value
+		}
+	}
+}
+
+// encodeValue returns the value to put into map, it returns false if
+// the value should be omitted.
+// The slices, arrays and maps with string keys are encoded into []interface{}
+// and map[string]interface{} if their elements may contain structs, the nil
+// elements are kept in slices but omitted in maps.
+func encodeValue(v reflect.Value, o *Options) (interface{}, bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+		return encodeValue(v.Elem(), o)
+	case reflect.Struct:
+		if isOptional(v.Type()) {
+			if !v.FieldByName("Set").Bool() {
+				return nil, false
+			}
+			return encodeValue(v.FieldByName("Value"), o)
+		}
+		if hasExportedField(v.Type()) {
+			return encodeStruct(v, o), true
+		}
+	case reflect.Slice, reflect.Array:
+		if !needsEncoding(v.Type().Elem()) {
+			break
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return []interface{}(nil), true
+		}
+		ret := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			ret[i], _ = encodeValue(v.Index(i), o)
+		}
+		return ret, true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || !needsEncoding(v.Type().Elem()) {
+			break
+		}
+		if v.IsNil() {
+			return map[string]interface{}(nil), true
+		}
+		ret := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			if value, ok := encodeValue(iter.Value(), o); ok {
+				ret[iter.Key().String()] = value
+			}
+		}
+		return ret, true
+	}
+	return v.Interface(), true
+}
+
+// needsEncoding reports whether the values of type t may be changed by encodeValue
+func needsEncoding(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return needsEncoding(t.Elem())
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && needsEncoding(t.Elem())
+	case reflect.Struct:
+		return isOptional(t) || hasExportedField(t)
+	}
+	return false
+}
+
+// hasExportedField reports whether the struct has any exported field, including
+// the ones promoted from embedded structs
+func hasExportedField(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) == 0 {
+			return true
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && hasExportedField(field.Type) {
+			return true
+		}
+	}
+	return false
+}
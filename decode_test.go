@@ -0,0 +1,314 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+var _ = Describe("map to struct", func() {
+	type Meta struct {
+		Name   string
+		Labels map[string]string `json:"labels"`
+	}
+	type container struct {
+		Image string `json:"image"`
+		Port  int    `merge:"containerPort" json:"port"`
+	}
+	type spec struct {
+		Meta
+		Replicas  *int      `json:"replicas,omitempty"`
+		Container container `json:"container"`
+		Ignored   string    `json:"-"`
+		internal  string
+	}
+
+	var dst spec
+
+	BeforeEach(func() {
+		dst = spec{
+			Meta: Meta{
+				Name:   "a",
+				Labels: map[string]string{"a": "a"},
+			},
+			Container: container{Image: "a", Port: 80},
+			Ignored:   "a",
+			internal:  "a",
+		}
+	})
+
+	It("decodes map into struct", func() {
+		src := map[string]interface{}{
+			"Name":     "b",
+			"labels":   map[string]string{"b": "b"},
+			"replicas": 2,
+			"container": map[string]interface{}{
+				"image":         "b",
+				"port":          81,
+				"containerPort": int32(8080),
+			},
+			"Ignored":  "b",
+			"internal": "b",
+			"unknown":  "b",
+		}
+		err := Merge(&dst, src)
+		Expect(err).To(BeNil())
+		replicas := 2
+		Expect(dst).To(Equal(spec{
+			Meta: Meta{
+				Name:   "b",
+				Labels: map[string]string{"a": "a", "b": "b"},
+			},
+			Replicas:  &replicas,
+			Container: container{Image: "b", Port: 8080},
+			Ignored:   "a",
+			internal:  "a",
+		}))
+	})
+
+	It("without overwrite", func() {
+		err := Merge(&dst, map[string]interface{}{"Name": "b", "replicas": 2}, WithoutOverwrite)
+		Expect(err).To(BeNil())
+		Expect(dst.Name).To(Equal("a"))
+		Expect(*dst.Replicas).To(Equal(2))
+	})
+
+	It("case insensitive keys", func() {
+		err := Merge(&dst, map[string]interface{}{"NAME": "b"})
+		Expect(err).To(BeNil())
+		Expect(dst.Name).To(Equal("a"))
+		err = Merge(&dst, map[string]interface{}{"NAME": "b"}, WithCaseInsensitiveKeys)
+		Expect(err).To(BeNil())
+		Expect(dst.Name).To(Equal("b"))
+	})
+
+	It("tag names", func() {
+		err := Merge(&dst, map[string]interface{}{"container": map[string]interface{}{"port": 81}}, WithTagNames("json"))
+		Expect(err).To(BeNil())
+		Expect(dst.Container.Port).To(Equal(81))
+	})
+
+	It("map[interface{}]interface{}", func() {
+		err := Merge(&dst, map[interface{}]interface{}{"Name": "b"})
+		Expect(err).To(BeNil())
+		Expect(dst.Name).To(Equal("b"))
+		err = Merge(&dst, map[interface{}]interface{}{1: "b"})
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("into interface{} and pointer", func() {
+		var iface interface{} = container{Image: "a"}
+		err := Merge(&iface, map[string]interface{}{"containerPort": 80})
+		Expect(err).To(BeNil())
+		Expect(iface).To(Equal(container{Image: "a", Port: 80}))
+
+		var ptr *container
+		err = Merge(&ptr, map[string]interface{}{"image": "b"})
+		Expect(err).To(BeNil())
+		Expect(ptr).To(Equal(&container{Image: "b"}))
+	})
+
+	It("struct without exported fields", func() {
+		created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		err := Merge(&created, map[string]interface{}{"wall": 1})
+		Expect(err).NotTo(BeNil())
+		Expect(created).To(Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+		type withTime struct {
+			Created time.Time
+		}
+		wt := withTime{}
+		err = Merge(&wt, map[string]interface{}{"Created": map[string]interface{}{}})
+		Expect(err).NotTo(BeNil())
+		Expect(err.(*MergeError).Path).To(Equal("Created"))
+	})
+
+	It("reports the path of error", func() {
+		err := Merge(&dst, map[string]interface{}{"container": map[string]interface{}{"image": 1}})
+		Expect(err).NotTo(BeNil())
+		Expect(err.(*MergeError).Path).To(Equal("Container.Image"))
+	})
+})
+
+var _ = Describe("struct to map", func() {
+	type Meta struct {
+		Name string `json:"name"`
+	}
+	type container struct {
+		Image string `json:"image,omitempty"`
+		Port  int    `json:"port,omitempty"`
+	}
+	type spec struct {
+		Meta      `json:",inline"`
+		Replicas  *int            `json:"replicas"`
+		Enabled   Optional[bool]  `json:"enabled"`
+		Container container       `json:"container"`
+		Created   time.Time       `json:"created"`
+		Ignored   string          `json:"-"`
+		Env       []string        `json:"env,omitempty"`
+		Extra     map[string]bool `json:"extra"`
+	}
+
+	It("encodes struct into map", func() {
+		created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		dst := map[string]interface{}{
+			"name": "a",
+			"container": map[string]interface{}{
+				"image": "a",
+				"port":  80,
+				"args":  []string{"a"},
+			},
+			"env":     []string{"a"},
+			"other":   "a",
+			"extra":   map[string]bool{"a": true},
+			"enabled": true,
+		}
+		src := spec{
+			Meta:      Meta{Name: "b"},
+			Container: container{Image: "b"},
+			Created:   created,
+			Ignored:   "b",
+			Extra:     map[string]bool{"b": true},
+		}
+		err := Merge(&dst, src)
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(map[string]interface{}{
+			"name": "b",
+			"container": map[string]interface{}{
+				"image": "b",
+				"port":  80,
+				"args":  []string{"a"},
+			},
+			"created": created,
+			"env":     []string{"a"},
+			"other":   "a",
+			"extra":   map[string]bool{"a": true, "b": true},
+			"enabled": true,
+		}))
+	})
+
+	It("encodes pointers and optionals", func() {
+		replicas := 2
+		dst := map[string]interface{}{}
+		err := Merge(&dst, &spec{Replicas: &replicas, Enabled: Some(false)})
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(map[string]interface{}{
+			"name":      "",
+			"replicas":  2,
+			"enabled":   false,
+			"container": map[string]interface{}{},
+			"created":   time.Time{},
+			"extra":     map[string]bool(nil),
+		}))
+	})
+
+	It("encodes nested slices and maps", func() {
+		type port struct {
+			Name string `json:"name"`
+			Port int    `json:"port,omitempty"`
+		}
+		type pod struct {
+			Ports      []port                 `json:"ports"`
+			PortPtrs   []*port                `json:"portPtrs"`
+			Groups     [][]port               `json:"groups"`
+			Named      map[string]port        `json:"named"`
+			NamedLists map[string][]*port     `json:"namedLists"`
+			Fixed      [1]port                `json:"fixed"`
+			Any        map[string]interface{} `json:"any"`
+			Times      []time.Time            `json:"times"`
+			Labels     map[string]string      `json:"labels"`
+		}
+		created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		dst := map[string]interface{}{}
+		src := pod{
+			Ports:      []port{{Name: "http", Port: 80}},
+			PortPtrs:   []*port{{Name: "a"}, nil},
+			Groups:     [][]port{{{Name: "b", Port: 81}}},
+			Named:      map[string]port{"c": {Name: "c"}},
+			NamedLists: map[string][]*port{"d": {{Name: "d", Port: 82}}},
+			Fixed:      [1]port{{Name: "e"}},
+			Any:        map[string]interface{}{"f": port{Name: "f"}, "g": 1, "h": nil},
+			Times:      []time.Time{created},
+			Labels:     map[string]string{"a": "b"},
+		}
+		err := Merge(&dst, src)
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(map[string]interface{}{
+			"ports":      []interface{}{map[string]interface{}{"name": "http", "port": 80}},
+			"portPtrs":   []interface{}{map[string]interface{}{"name": "a"}, nil},
+			"groups":     []interface{}{[]interface{}{map[string]interface{}{"name": "b", "port": 81}}},
+			"named":      map[string]interface{}{"c": map[string]interface{}{"name": "c"}},
+			"namedLists": map[string]interface{}{"d": []interface{}{map[string]interface{}{"name": "d", "port": 82}}},
+			"fixed":      []interface{}{map[string]interface{}{"name": "e"}},
+			"any":        map[string]interface{}{"f": map[string]interface{}{"name": "f"}, "g": 1},
+			"times":      []time.Time{created},
+			"labels":     map[string]string{"a": "b"},
+		}))
+	})
+
+	It("struct without exported fields", func() {
+		dst := map[string]interface{}{}
+		err := Merge(&dst, time.Time{})
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("struct with unexported fields", func() {
+		type inner struct {
+			Value int
+			cache string
+		}
+		type withHidden struct {
+			Name  string
+			Inner inner
+			mu    sync.Mutex
+			count int
+		}
+		dst := map[string]interface{}{"Name": "a", "Other": 1}
+		err := Merge(&dst, &withHidden{Name: "b", Inner: inner{Value: 1, cache: "c"}, count: 1})
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(map[string]interface{}{
+			"Name":  "b",
+			"Other": 1,
+			"Inner": map[string]interface{}{"Value": 1},
+		}))
+	})
+})
+
+var _ = Describe("parseFieldTag", func() {
+	type test struct {
+		A string
+		B string `json:"b,omitempty"`
+		C string `merge:"unit=bytes" json:"c"`
+		D string `merge:"d" json:"-"`
+		E string `json:"-"`
+	}
+
+	DescribeTable(
+		"",
+		func(name string, want fieldTag) {
+			field, _ := reflect.TypeOf(test{}).FieldByName(name)
+			Expect(parseFieldTag(field, opts)).To(Equal(want))
+		},
+		Entry("no tag", "A", fieldTag{name: "A"}),
+		Entry("json", "B", fieldTag{name: "b", options: []string{"omitempty"}}),
+		Entry("options in merge tag", "C", fieldTag{name: "c", options: []string{"unit=bytes"}}),
+		Entry("merge tag takes precedence", "D", fieldTag{name: "d"}),
+		Entry("skip", "E", fieldTag{skip: true}),
+	)
+})
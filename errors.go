@@ -0,0 +1,67 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MergeError records an error and the path of the value where it occurs
+type MergeError struct {
+	// Path is the path of the value from the target, e.g. Spec.Containers[0].Env
+	Path string
+	Err  error
+}
+
+func (e *MergeError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *MergeError) Unwrap() error {
+	return e.Err
+}
+
+// wrapError wraps err into a *MergeError with the current path, if err is
+// already a *MergeError, it is returned as is to keep the deepest path.
+func (o *Options) wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var mergeErr *MergeError
+	if errors.As(err, &mergeErr) {
+		return err
+	}
+	return &MergeError{
+		Path: o.path.String(),
+		Err:  err,
+	}
+}
+
+// recoverPanic recovers the panic and sets it to err as a *MergeError
+func (o *Options) recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = &MergeError{
+			Path: o.path.String(),
+			Err:  fmt.Errorf("panic: %v", r),
+		}
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"errors"
+)
+
+var _ = Describe("merge error", func() {
+	DescribeTable(
+		"error message",
+		func(err *MergeError, want string) {
+			Expect(err.Error()).To(Equal(want))
+		},
+		Entry("without path", &MergeError{Err: errors.New("failed")}, "failed"),
+		Entry("with path", &MergeError{Path: "Spec.Labels", Err: errors.New("failed")}, "Spec.Labels: failed"),
+	)
+
+	It("keeps the deepest path", func() {
+		type inner struct {
+			Value interface{}
+		}
+		type outer struct {
+			Inner map[string]inner
+		}
+		dst := outer{Inner: map[string]inner{"a": {Value: "1"}}}
+		src := outer{Inner: map[string]inner{"a": {Value: 1}}}
+		err := Merge(&dst, src)
+		Expect(err).NotTo(BeNil())
+		var mergeErr *MergeError
+		Expect(errors.As(err, &mergeErr)).To(BeTrue())
+		Expect(mergeErr.Path).To(Equal("Inner[a].Value"))
+	})
+})
@@ -1,6 +1,6 @@
 module github.com/zoumo/gomerge
 
-go 1.13
+go 1.18
 
 require (
 	github.com/hpcloud/tail v1.0.1-0.20180514194441-a1dbeea552b7
@@ -18,17 +18,80 @@ package gomerge
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"time"
 )
 
+// ErrUseDefault can be returned (or wrapped) by custom merge funcs to
+// indicate that they do not handle the values, and the default merge
+// logic of struct, map, slice, etc. should be applied instead.
+var ErrUseDefault = errors.New("gomerge: use default merge")
+
 // Options ..
 type Options struct {
-	Overwrite      bool
-	GoConvertion   bool
+	Overwrite bool
+	// OverwriteWithEmptySrc allows the empty src to overwrite dst when Overwrite is true,
+	// disable it to use the src as a patch which only contains the values to change
+	OverwriteWithEmptySrc bool
+	GoConvertion          bool
+	// StrictNumeric returns an error when numeric conversion overflows, loses
+	// sign or fraction, otherwise the loss is added to Report
+	StrictNumeric bool
+	// Report collects the notable events during merging, e.g. lossy conversions
+	Report *Report
+	// TimeConvertion enables the conversions from string or number to
+	// time.Duration and time.Time, and the reverse to string
+	TimeConvertion bool
+	// DurationUnit is the unit of number when converting it to time.Duration
+	DurationUnit time.Duration
+	// TimeLayout is the layout of string when converting it to time.Time
+	TimeLayout string
+	// TextConvertion enables the conversions by encoding.TextUnmarshaler
+	// and encoding.TextMarshaler
+	TextConvertion bool
+	// JSONNumberConvertion enables the exact conversions between json.Number
+	// and number
+	JSONNumberConvertion bool
+	// StrConvertion enables the conversions between string and number or bool
+	StrConvertion bool
+	// Unit is the unit of quantity string when converting it to number, see
+	// UnitBytes, UnitPercent and UnitRatio. It can also be set by the struct
+	// tag like `merge:"unit=bytes"` for a field.
+	Unit           string
 	SliceMode      SliceMergeMode
 	AppendSlice    bool
 	IntersectSlice bool
-	delegate       *porter
+	// ScalarToSlice wraps a non-slice src into a slice with one element
+	// when dst is a slice
+	ScalarToSlice bool
+	// SliceSeparator splits a string src into a slice when dst is a slice,
+	// and joins a slice src into a string when dst is a string. It is
+	// disabled if the separator is empty.
+	SliceSeparator string
+	// TagNames are the struct tags used to find the key of field when
+	// converting between struct and map, the former takes precedence
+	TagNames []string
+	// CaseInsensitiveKeys matches the keys of map and the names of fields
+	// case-insensitively when converting map to struct
+	CaseInsensitiveKeys bool
+	// RecoverPanic recovers the panic raised during merging, including the
+	// ones from custom funcs, and returns it as a *MergeError
+	RecoverPanic bool
+	delegate     *porter
+	// err records the first error occurs when applying options
+	err error
+
+	// path of the value being merged
+	path         fieldPath
+	parent       reflect.Value
+	field        *reflect.StructField
+	fieldOptions []fieldOptions
+}
+
+type fieldOptions struct {
+	pattern fieldPath
+	opts    []func(*Options)
 }
 
 // SliceMergeMode specify which merge strategy will be applied
@@ -52,10 +115,94 @@ const (
 
 func newOptions() *Options {
 	return &Options{
-		Overwrite:    true,
-		GoConvertion: true,
-		SliceMode:    ReplaceSlice,
-		delegate:     newPorter(),
+		Overwrite:             true,
+		OverwriteWithEmptySrc: true,
+		GoConvertion:          true,
+		TimeConvertion:        true,
+		TextConvertion:        true,
+		JSONNumberConvertion:  true,
+		DurationUnit:          time.Nanosecond,
+		TimeLayout:            time.RFC3339,
+		SliceMode:             ReplaceSlice,
+		TagNames:              []string{"merge", "json"},
+		delegate:              newPorter(),
+	}
+}
+
+// clone returns a copy of o with the given options applied. The porter is
+// shared until an option needs to change it, see WithMergeFuncs.
+func (o *Options) clone(opts ...func(*Options)) *Options {
+	c := *o
+	for _, f := range opts {
+		f(&c)
+	}
+	return &c
+}
+
+// at returns the options for merging the child of parent at the given
+// path segment, the field options matching the child path are applied.
+func (o *Options) at(parent reflect.Value, seg string) *Options {
+	c := *o
+	c.path = o.path.append(seg)
+	c.parent = parent
+	c.field = nil
+	for _, f := range o.fieldOptions {
+		if c.path.match(f.pattern) {
+			for _, opt := range f.opts {
+				opt(&c)
+			}
+		}
+	}
+	return &c
+}
+
+// atField returns the options for merging the struct field of parent
+func (o *Options) atField(parent reflect.Value, field reflect.StructField) *Options {
+	c := o.at(parent, field.Name)
+	c.field = &field
+	if unit, ok := parseFieldTag(field, o).get("unit"); ok {
+		c.Unit = unit
+	}
+	return c
+}
+
+// Context returns the context of the value being merged
+func (o *Options) Context() *MergeContext {
+	return &MergeContext{
+		Path:    o.path.String(),
+		Parent:  o.parent,
+		Field:   o.field,
+		Depth:   len(o.path),
+		Options: o,
+	}
+}
+
+// Merge merges src onto dst with the options at current path, the dst must be
+// a pointer. It is designed to be used inside custom funcs to deep merge a part
+// of the values, e.g.
+//
+//	func(dst, src Spec, o *Options) (Spec, error) {
+//		if err := o.Merge(&dst.Template, src.Template); err != nil {
+//			return dst, err
+//		}
+//		...
+//	}
+//
+// Be careful not to merge the same type as the custom func, it will call
+// the custom func again.
+func (o *Options) Merge(dst, src interface{}) error {
+	return merge(dst, src, o)
+}
+
+// MergeValue is like Merge but accepts reflect.Value, the dst must be settable.
+// The returned error is a *MergeError containing the path where it occurs.
+func (o *Options) MergeValue(dst, src reflect.Value) error {
+	return o.wrapError(o.delegate.defaultMerge(dst, src, o))
+}
+
+func (o *Options) addError(err error) {
+	if o.err == nil {
+		o.err = err
 	}
 }
 
@@ -64,11 +211,101 @@ func WithoutOverwrite(o *Options) {
 	o.Overwrite = false
 }
 
+// WithoutOverwriteWithEmptySrc overwrites dst only with the non-empty src,
+// so that a partial struct can be used as a patch
+func WithoutOverwriteWithEmptySrc(o *Options) {
+	o.OverwriteWithEmptySrc = false
+}
+
 // WithoutGoConvertion disables the golang defaultMerge rules
 func WithoutGoConvertion(o *Options) {
 	o.GoConvertion = false
 }
 
+// WithTagNames changes the struct tags used to find the key of field when converting
+// between struct and map, the former takes precedence. The default tags are
+// "merge" and "json", e.g.
+//
+//	type Config struct {
+//		Port int `json:"port"`
+//	}
+//
+// the "port" key of map will be merged into Port.
+func WithTagNames(names ...string) func(*Options) {
+	return func(o *Options) {
+		o.TagNames = names
+	}
+}
+
+// WithCaseInsensitiveKeys matches the keys of map and the names of fields
+// case-insensitively when converting map to struct
+func WithCaseInsensitiveKeys(o *Options) {
+	o.CaseInsensitiveKeys = true
+}
+
+// WithRecoverPanic recovers the panic raised during merging into a *MergeError,
+// so that a bad custom func can not crash the program
+func WithRecoverPanic(o *Options) {
+	o.RecoverPanic = true
+}
+
+// WithStrictNumeric returns an error when numeric conversion overflows, loses sign
+// or fraction, e.g. int64(300) to int8, -1 to uint, 1.9 to int
+func WithStrictNumeric(o *Options) {
+	o.StrictNumeric = true
+}
+
+// WithReport collects the notable events during merging into the report,
+// e.g. the lossy numeric conversions when StrictNumeric is false
+func WithReport(r *Report) func(*Options) {
+	return func(o *Options) {
+		o.Report = r
+	}
+}
+
+// WithoutTimeConvertion disables the conversions of time.Duration and time.Time
+func WithoutTimeConvertion(o *Options) {
+	o.TimeConvertion = false
+}
+
+// WithDurationUnit changes the unit of number when converting it to time.Duration,
+// e.g. WithDurationUnit(time.Second) converts 5 to 5s. The default unit is
+// time.Nanosecond
+func WithDurationUnit(unit time.Duration) func(*Options) {
+	return func(o *Options) {
+		o.DurationUnit = unit
+	}
+}
+
+// WithTimeLayout changes the layout of string when converting it to or from
+// time.Time, the default layout is time.RFC3339
+func WithTimeLayout(layout string) func(*Options) {
+	return func(o *Options) {
+		o.TimeLayout = layout
+	}
+}
+
+// WithoutTextConvertion disables the conversions by encoding.TextUnmarshaler
+// and encoding.TextMarshaler. By default, the string or []byte can be converted
+// to a type whose pointer implements encoding.TextUnmarshaler, e.g. net.IP, and
+// a type implementing encoding.TextMarshaler can be converted to string.
+func WithoutTextConvertion(o *Options) {
+	o.TextConvertion = false
+}
+
+// WithoutJSONNumberConvertion disables the conversions between json.Number and
+// number. By default, json.Number is converted to int, uint or float exactly,
+// and it is rejected if it is not integral or overflows for the integer target.
+func WithoutJSONNumberConvertion(o *Options) {
+	o.JSONNumberConvertion = false
+}
+
+// WithStrConvertion enables the conversions between string and number or bool
+// by strconv, e.g. "8080" to int, "yes" or "on" to true, 1.5 to "1.5"
+func WithStrConvertion(o *Options) {
+	o.StrConvertion = true
+}
+
 // WithSliceMode changes slice merge mode
 func WithSliceMode(mode SliceMergeMode) func(*Options) {
 	return func(o *Options) {
@@ -76,17 +313,73 @@ func WithSliceMode(mode SliceMergeMode) func(*Options) {
 	}
 }
 
+// WithUnit converts the quantity string in the unit to number, e.g. "512Mi" to
+// 536870912 with UnitBytes. It is usually used with WithFieldOptions, or set by
+// the struct tag like `merge:"unit=bytes"` instead.
+func WithUnit(unit string) func(*Options) {
+	return func(o *Options) {
+		if !isUnit(unit) {
+			o.addError(fmt.Errorf("unknown unit %q", unit))
+			return
+		}
+		o.Unit = unit
+	}
+}
+
+// WithScalarToSlice wraps a non-slice src into a slice with one element when dst
+// is a slice, e.g. "foo" to []string{"foo"}
+func WithScalarToSlice(o *Options) {
+	o.ScalarToSlice = true
+}
+
+// WithStringSplit splits a string src by the separator when dst is a slice, e.g.
+// "foo, bar" to []string{"foo", "bar"}, and joins the elements of a slice src
+// by the separator when dst is a string. The elements are converted to the
+// element type of dst or string following the normal convertion rules.
+func WithStringSplit(sep string) func(*Options) {
+	return func(o *Options) {
+		o.SliceSeparator = sep
+	}
+}
+
+// WithEmptyFunc add a custom func to determine whether the value of the given
+// type is empty, it affects whether dst can be overwritten when Overwrite is
+// false. By default, a value is empty if its IsZero() method returns true, or
+// it is a zero value (all fields are zero for a struct), an empty string, map
+// or slice.
+func WithEmptyFunc(typ reflect.Type, fn func(reflect.Value) bool) func(*Options) {
+	return func(o *Options) {
+		if typ == nil {
+			o.addError(fmt.Errorf("expected type for empty func, got nil"))
+			return
+		}
+		if fn == nil {
+			o.addError(fmt.Errorf("expected empty func for %v, got nil", typ))
+			return
+		}
+		o.delegate = o.delegate.clone()
+		o.delegate.emptyFuncs[typ] = fn
+	}
+}
+
 // WithConverters add custom convert funcs, func sign is like:
 //
 // func(dst string, src int, o *Options) (string, error) {}
 //
+// or like
+//
+// func(ctx *MergeContext, dst string, src int) (string, error) {}
+//
 // It follows the rules:
 // - the function must have three params
 // - the function must have tow return values
-// - the first param and first return must be the same type
-// - the third param must be *Option
+// - the dst param and first return must be the same type
+// - the third param must be *Option, or the first param must be *MergeContext
 // - the last return must be error
-// - the first and second params should be different type
+// - the dst and src params should be different type
+//
+// The converters can be chained, e.g. with the converters from A to B and from
+// B to C, A is converted to C through B. The shortest chain is used.
 func WithConverters(fns ...interface{}) func(*Options) {
 	return WithMergeFuncs(fns...)
 }
@@ -95,17 +388,169 @@ func WithConverters(fns ...interface{}) func(*Options) {
 //
 // func(dst, src int, o *Options) (int, error) {}
 //
+// or like the following one if the func needs to know where the value is
+//
+// func(ctx *MergeContext, dst, src int) (int, error) {}
+//
 // It follows the rules:
 // - the function must have three params
 // - the function must have tow return values
-// - the first, second param and first return must be the same type
-// - the third param must be *Option
+// - the dst, src param and first return must be the same type
+// - the third param must be *Option, or the first param must be *MergeContext
 // - the last return must be error
+//
+// If the first param is a non-empty interface, e.g.
+//
+// func(dst, src fmt.Stringer, o *Options) (fmt.Stringer, error) {}
+//
+// the function will be applied to all types implementing it, unless there is
+// a function registered for the exact type. The returned value must be the
+// same type as dst.
 func WithMergeFuncs(fns ...interface{}) func(*Options) {
 	return func(o *Options) {
+		// copy on write, the porter may be shared with other Options
+		o.delegate = o.delegate.clone()
 		err := o.delegate.addCustomFuncs(fns...)
 		if err != nil {
-			panic(err)
+			o.addError(err)
+		}
+	}
+}
+
+// WithStdConverters adds the converters for the common types in standard library:
+//   - net.IP and *net.IPNet from string like "10.0.0.1" and "10.0.0.0/8"
+//   - *url.URL from string
+//   - *regexp.Regexp from pattern
+//   - []byte from base64 string, and the reverse like encoding/json
+//   - big.Int and big.Float (or pointers to them) from string, json.Number and number
+//
+// They replace the converters registered before for the same types, so
+// register the custom ones after it to override. They are not used to
+// chain converters, see WithConverters.
+func WithStdConverters(o *Options) {
+	// copy on write, the porter may be shared with other Options
+	o.delegate = o.delegate.clone()
+	if err := o.delegate.addStdConverters(); err != nil {
+		o.addError(err)
+	}
+}
+
+// Merger merges entities following the options given when it is created.
+// The options and custom funcs are resolved only once, and a Merger is safe
+// for concurrent use by multiple goroutines.
+type Merger struct {
+	opts *Options
+}
+
+// New creates a Merger with the given options, an error is returned if any of
+// the options is invalid, e.g. a custom func with wrong signature.
+func New(opts ...func(*Options)) (*Merger, error) {
+	o := newOptions().clone(opts...)
+	if o.err != nil {
+		return nil, o.err
+	}
+	return &Merger{
+		opts: o,
+	}, nil
+}
+
+// Clone returns a new Merger which inherits all options and custom funcs
+// of m and applies the given options on top of them. m is not changed.
+func (m *Merger) Clone(opts ...func(*Options)) (*Merger, error) {
+	o := m.opts.clone(opts...)
+	if o.err != nil {
+		return nil, o.err
+	}
+	return &Merger{
+		opts: o,
+	}, nil
+}
+
+// Merge the given source onto the given target following the options of m.
+// The given options only take effect in this call.
+func (m *Merger) Merge(dst, src interface{}, opts ...func(*Options)) error {
+	o := m.opts
+	if len(opts) > 0 {
+		o = o.clone(opts...)
+		if o.err != nil {
+			return o.err
+		}
+	}
+	return merge(dst, src, o)
+}
+
+// WithFieldMergeFunc add a custom merge func which is only applied to the value
+// at the matching path, the func sign is the same as WithMergeFuncs. The path is
+// relative to the target, such as
+//
+//	Spec.Template.Labels
+//
+// "*" matches any struct field and "[*]" matches any slice index or map key, e.g.
+//
+//	Spec.Containers[*].Env
+//
+// A field merge func takes precedence over the funcs registered for types.
+//
+// If the path goes into the elements of a slice, e.g. "Spec.Containers[*].Env",
+// the slice is no longer replaced or appended as a whole. The src elements are
+// merged into the dst elements at the same index one by one, so the other fields
+// of the elements follow the normal merge rules too, e.g. an empty field of src
+// element does not overwrite dst with WithoutOverwriteWithEmptySrc.
+func WithFieldMergeFunc(path string, fn interface{}) func(*Options) {
+	return func(o *Options) {
+		o.delegate = o.delegate.clone()
+		err := o.delegate.addFieldFunc(parseFieldPath(path), fn)
+		if err != nil {
+			o.addError(err)
+		}
+	}
+}
+
+// WithFieldOptions applies the options to the value at the matching path and all
+// values beneath it, see WithFieldMergeFunc for the path syntax. Like a field merge
+// func, a path going into the elements of a slice makes the slice merged element
+// by element.
+func WithFieldOptions(path string, opts ...func(*Options)) func(*Options) {
+	return func(o *Options) {
+		// apply the options in advance to find out errors
+		if c := o.clone(opts...); c.err != nil {
+			o.addError(c.err)
+			return
+		}
+		// make a copy, the slice may be shared with other Options
+		o.fieldOptions = append(o.fieldOptions[:len(o.fieldOptions):len(o.fieldOptions)], fieldOptions{
+			pattern: parseFieldPath(path),
+			opts:    opts,
+		})
+	}
+}
+
+// ValueMergeFunc merges src onto dst and returns the merged value, the dst and src are
+// the same type and the returned value must be the type of dst.
+type ValueMergeFunc func(dst, src reflect.Value, o *Options) (reflect.Value, error)
+
+// WithKindMergeFunc add a custom merge func for all types of the given kind,
+// e.g. reflect.String covers string and all named string types.
+//
+// If more than one custom merge func matches a type, the precedence is
+// exact type (WithMergeFuncs) > interface (WithMergeFuncs) >
+// predicate (WithPredicateMergeFunc) > kind
+func WithKindMergeFunc(kind reflect.Kind, fn ValueMergeFunc) func(*Options) {
+	return func(o *Options) {
+		o.delegate = o.delegate.clone()
+		if err := o.delegate.addKindFunc(kind, fn); err != nil {
+			o.addError(err)
+		}
+	}
+}
+
+// WithPredicateMergeFunc add a custom merge func for all types matched by the
+// predicate. Predicates are evaluated in the order they are added.
+func WithPredicateMergeFunc(match func(reflect.Type) bool, fn ValueMergeFunc) func(*Options) {
+	return func(o *Options) {
+		o.delegate = o.delegate.clone()
+		if err := o.delegate.addPredicateFunc(match, fn); err != nil {
+			o.addError(err)
 		}
 	}
 }
@@ -114,12 +559,11 @@ func WithMergeFuncs(fns ...interface{}) func(*Options) {
 // must be a pointer. Merge will accept any two entities, even if their types are diffrent
 // as long as there is convert function (see WithConverters).
 func Merge(dst, src interface{}, opts ...func(*Options)) error {
-	o := newOptions()
-
-	for _, f := range opts {
-		f(o)
+	m, err := New(opts...)
+	if err != nil {
+		return err
 	}
-	return merge(dst, src, o)
+	return m.Merge(dst, src)
 }
 
 func merge(dst, src interface{}, o *Options) error {
@@ -129,12 +573,14 @@ func merge(dst, src interface{}, o *Options) error {
 
 	vDst, vSrc, err := resolveValues(dst, src)
 	if err != nil {
-		return err
+		return o.wrapError(err)
 	}
 
-	// make a copy to let dst stay in tact when an error occurs
-	vDstCopy := vDst
-	err = o.delegate.defaultMerge(vDstCopy, vSrc, o)
+	// make a copy to let dst stay in tact when an error occurs, the nil
+	// pointers in it are allocated during merging and set back at the end
+	vDstCopy := reflect.New(vDst.Type()).Elem()
+	vDstCopy.Set(vDst)
+	err = o.MergeValue(vDstCopy, vSrc)
 	if err != nil {
 		return err
 	}
@@ -154,11 +600,13 @@ func resolveValues(dst, src interface{}) (vDst, vSrc reflect.Value, err error) {
 		err = errors.New("the target must be a pointer")
 		return
 	}
-	vDst = derefPtr(vDst)
-	if !vDst.IsValid() {
+	if vDst.IsNil() {
 		err = errors.New("target can not be zero value")
 		return
 	}
+	// the nil pointers behind target will be allocated when merging, e.g. if
+	// target is a **T pointing at a nil *T, a new T will be allocated
+	vDst = vDst.Elem()
 
 	// we dereference the src if it is a pointer
 	vSrc = derefPtr(reflect.ValueOf(src))
@@ -17,7 +17,13 @@ limitations under the License.
 package gomerge
 
 import (
+	"errors"
 	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/onsi/gomega"
 )
 
 var _ = Describe("options", func() {
@@ -37,6 +43,12 @@ var _ = Describe("options", func() {
 			func(o *Options) bool {
 				return o.Overwrite == false
 			}),
+		Entry(
+			"without overwrite with empty src",
+			WithoutOverwriteWithEmptySrc,
+			func(o *Options) bool {
+				return o.OverwriteWithEmptySrc == false
+			}),
 		Entry(
 			"without go convertion",
 			WithoutGoConvertion,
@@ -56,6 +68,20 @@ var _ = Describe("options", func() {
 				return len(o.delegate.mergeFuncs) == 1
 			},
 		),
+		Entry(
+			"with kind merge func",
+			WithKindMergeFunc(reflect.String, func(dst, src reflect.Value, o *Options) (reflect.Value, error) { return src, nil }),
+			func(o *Options) bool {
+				return len(o.delegate.kindFuncs) == 1
+			},
+		),
+		Entry(
+			"with predicate merge func",
+			WithPredicateMergeFunc(func(reflect.Type) bool { return true }, func(dst, src reflect.Value, o *Options) (reflect.Value, error) { return src, nil }),
+			func(o *Options) bool {
+				return len(o.delegate.predicateFuncs) == 1
+			},
+		),
 	)
 })
 
@@ -78,6 +104,7 @@ var _ = Describe("resolve values", func() {
 		Entry("dst is invalid", (*int)(nil), nil, reflect.Int, reflect.Invalid, true),
 		Entry("src is nil", &v1, nil, reflect.Int, reflect.Invalid, true),
 		Entry("simple", &v1, &v2, reflect.Int, reflect.String, false),
+		Entry("dst is ptr to nil ptr", new(*int), &v2, reflect.Ptr, reflect.String, false),
 	)
 })
 
@@ -121,4 +148,437 @@ var _ = Describe("Merge", func() {
 			Map:    map[string]string{"1": "1"},
 		}))
 	})
+	It("with overwrite, without empty src", func() {
+		dst = test{
+			Int:    1,
+			String: "1",
+			Bool:   true,
+			Slice:  []string{"1"},
+		}
+		src = test{
+			String: "2",
+			Slice:  []string{},
+		}
+		err := Merge(&dst, src, WithoutOverwriteWithEmptySrc)
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(test{
+			Int:    1,
+			String: "2",
+			Bool:   true,
+			Slice:  []string{"1"},
+		}))
+	})
+})
+
+var _ = Describe("Merge without overwrite", func() {
+	type test struct {
+		Time    time.Time
+		Nested  struct{ A, B string }
+		Address string
+	}
+
+	It("fills empty values", func() {
+		now := time.Now()
+		dst := test{Address: "localhost"}
+		src := test{Time: now, Nested: struct{ A, B string }{"a", "b"}, Address: "127.0.0.1"}
+		err := Merge(&dst, src, WithoutOverwrite, WithEmptyFunc(reflect.TypeOf(""), func(v reflect.Value) bool {
+			return v.String() == "" || v.String() == "localhost"
+		}))
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(src))
+	})
+})
+
+var _ = Describe("Merge into nil pointer", func() {
+	type config struct {
+		Name string
+		Port int
+	}
+	src := config{Name: "a", Port: 80}
+
+	It("*T", func() {
+		var dst *config
+		Expect(Merge(&dst, src)).To(BeNil())
+		Expect(dst).To(Equal(&src))
+	})
+	It("**T", func() {
+		var dst **config
+		Expect(Merge(&dst, &src)).To(BeNil())
+		Expect(**dst).To(Equal(src))
+	})
+	It("interface{} holding nil *T", func() {
+		var dst interface{} = (*config)(nil)
+		Expect(Merge(&dst, src)).To(BeNil())
+		Expect(dst).To(Equal(&src))
+	})
+	It("nil interface{}", func() {
+		var dst interface{}
+		Expect(Merge(&dst, src)).To(BeNil())
+		Expect(dst).To(Equal(src))
+	})
+	It("interface{} holding T", func() {
+		var dst interface{} = config{Name: "b"}
+		Expect(Merge(&dst, config{Port: 80})).To(BeNil())
+		Expect(dst).To(Equal(config{Name: "", Port: 80}))
+	})
+	It("interface{} holding *int with convertion", func() {
+		var dst interface{} = (*int)(nil)
+		Expect(Merge(&dst, int32(1))).To(BeNil())
+		Expect(*(dst.(*int))).To(Equal(1))
+	})
+	It("stays nil on error", func() {
+		var dst *config
+		Expect(Merge(&dst, "x")).NotTo(BeNil())
+		Expect(dst == nil).To(BeTrue())
+
+		var dst2 **config
+		Expect(Merge(&dst2, map[string]interface{}{"Port": "x"})).NotTo(BeNil())
+		Expect(dst2 == nil).To(BeTrue())
+	})
+	It("nil *T field", func() {
+		type test struct {
+			Config *config
+		}
+		dst := test{}
+		Expect(Merge(&dst, test{Config: &src})).To(BeNil())
+		Expect(dst.Config).To(Equal(&src))
+		Expect(dst.Config == &src).NotTo(BeTrue())
+	})
+})
+
+var _ = Describe("Merger", func() {
+	sum := func(dst, src int, o *Options) (int, error) {
+		return dst + src, nil
+	}
+
+	It("is safe for concurrent use", func() {
+		m, err := New(WithMergeFuncs(sum))
+		Expect(err).To(BeNil())
+		results := make([]int, 100)
+		wg := sync.WaitGroup{}
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				dst := i
+				Expect(m.Merge(&dst, 1)).To(BeNil())
+				results[i] = dst
+			}(i)
+		}
+		wg.Wait()
+		for i, got := range results {
+			Expect(got).To(Equal(i + 1))
+		}
+	})
+
+	It("clone does not change the origin", func() {
+		m, err := New(WithSliceMode(UniteSlice))
+		Expect(err).To(BeNil())
+		c, err := m.Clone(WithMergeFuncs(sum))
+		Expect(err).To(BeNil())
+		Expect(m.opts.delegate.mergeFuncs).To(gomega.HaveLen(0))
+		Expect(c.opts.delegate.mergeFuncs).To(gomega.HaveLen(1))
+		Expect(c.opts.SliceMode).To(Equal(UniteSlice))
+
+		dst := 1
+		Expect(c.Merge(&dst, 2)).To(BeNil())
+		Expect(dst).To(Equal(3))
+		Expect(m.Merge(&dst, 2)).To(BeNil())
+		Expect(dst).To(Equal(2))
+	})
+
+	It("per-call options only take effect in this call", func() {
+		m, err := New()
+		Expect(err).To(BeNil())
+		dst := 1
+		Expect(m.Merge(&dst, 2, WithoutOverwrite)).To(BeNil())
+		Expect(dst).To(Equal(1))
+		Expect(m.Merge(&dst, 2)).To(BeNil())
+		Expect(dst).To(Equal(2))
+	})
+
+	DescribeTable(
+		"invalid options",
+		func(opt func(*Options)) {
+			_, err := New(opt)
+			Expect(err).NotTo(BeNil())
+
+			m, err := New()
+			Expect(err).To(BeNil())
+			_, err = m.Clone(opt)
+			Expect(err).NotTo(BeNil())
+
+			dst := 1
+			Expect(m.Merge(&dst, 2, opt)).NotTo(BeNil())
+			Expect(Merge(&dst, 2, opt)).NotTo(BeNil())
+			Expect(dst).To(Equal(1))
+		},
+		Entry("merge func", WithMergeFuncs(func(dst, src int) int { return src })),
+		Entry("nil merge func", WithMergeFuncs(nil)),
+		Entry("field merge func", WithFieldMergeFunc("A", func(dst string, src int, o *Options) (string, error) { return dst, nil })),
+		Entry("field options", WithFieldOptions("A", WithMergeFuncs(1))),
+		Entry("nil kind merge func", WithKindMergeFunc(reflect.Int, nil)),
+		Entry("nil empty func type", WithEmptyFunc(nil, func(reflect.Value) bool { return true })),
+		Entry("nil empty func", WithEmptyFunc(reflect.TypeOf(0), nil)),
+		Entry("nil predicate", WithPredicateMergeFunc(nil, func(dst, src reflect.Value, o *Options) (reflect.Value, error) { return src, nil })),
+		Entry("nil predicate merge func", WithPredicateMergeFunc(func(reflect.Type) bool { return true }, nil)),
+	)
+})
+
+var _ = Describe("Merge with recover panic", func() {
+	type test struct {
+		Ptr *int
+		Str string
+	}
+	panicFunc := WithMergeFuncs(func(dst, src string, o *Options) (string, error) {
+		panic("bad func")
+	})
+
+	It("recovers panic into MergeError", func() {
+		dst := test{}
+		err := Merge(&dst, test{Str: "a"}, panicFunc, WithRecoverPanic)
+		Expect(err).NotTo(BeNil())
+		mergeErr, ok := err.(*MergeError)
+		Expect(ok).To(BeTrue())
+		Expect(mergeErr.Path).To(Equal("Str"))
+	})
+
+	It("panics without recover", func() {
+		dst := test{}
+		Expect(func() {
+			Merge(&dst, test{Str: "a"}, panicFunc)
+		}).To(gomega.Panic())
+	})
+})
+
+var _ = Describe("Merge with field options", func() {
+	type container struct {
+		Args []string
+		Env  map[string][]string
+	}
+	type spec struct {
+		Labels      []string
+		Annotations []string
+		Containers  map[string]container
+	}
+
+	var dst, src spec
+
+	BeforeEach(func() {
+		dst = spec{
+			Labels:      []string{"a"},
+			Annotations: []string{"a"},
+			Containers: map[string]container{
+				"c": {
+					Args: []string{"a"},
+					Env:  map[string][]string{"e": {"a"}},
+				},
+			},
+		}
+		src = spec{
+			Labels:      []string{"b"},
+			Annotations: []string{"b"},
+			Containers: map[string]container{
+				"c": {
+					Args: []string{"b"},
+					Env:  map[string][]string{"e": {"b"}},
+				},
+			},
+		}
+	})
+
+	It("field merge func", func() {
+		err := Merge(&dst, src, WithFieldMergeFunc("Labels", func(dst, src []string, o *Options) ([]string, error) {
+			return append(src, dst...), nil
+		}))
+		Expect(err).To(BeNil())
+		Expect(dst.Labels).To(Equal([]string{"b", "a"}))
+		Expect(dst.Annotations).To(Equal([]string{"b"}))
+	})
+
+	It("field options", func() {
+		err := Merge(&dst, src,
+			WithFieldOptions("Annotations", WithSliceMode(AppendSlice)),
+			WithFieldOptions("Containers[*]", WithoutOverwrite),
+		)
+		Expect(err).To(BeNil())
+		Expect(dst.Labels).To(Equal([]string{"b"}))
+		Expect(dst.Annotations).To(Equal([]string{"a", "b"}))
+		Expect(dst.Containers["c"]).To(Equal(container{
+			Args: []string{"a"},
+			Env:  map[string][]string{"e": {"a"}},
+		}))
+	})
+
+	It("glob", func() {
+		err := Merge(&dst, src, WithFieldOptions("Containers[*].*", WithSliceMode(UniteSlice)))
+		Expect(err).To(BeNil())
+		Expect(dst.Containers["c"]).To(Equal(container{
+			Args: []string{"a", "b"},
+			Env:  map[string][]string{"e": {"a", "b"}},
+		}))
+	})
+	It("field options changing custom funcs", func() {
+		type pair struct {
+			A, B int
+			C    string
+			D    []int
+		}
+		sum := func(dst, src int, o *Options) (int, error) {
+			return dst + src, nil
+		}
+		itoa := func(dst string, src int, o *Options) (string, error) {
+			return strconv.Itoa(src), nil
+		}
+		dst := pair{A: 1, B: 1, D: []int{1}}
+		err := Merge(&dst, pair{A: 2, B: 2, D: []int{2}},
+			WithFieldOptions("A", WithMergeFuncs(sum)),
+			WithFieldOptions("D[*]", WithMergeFuncs(sum)),
+		)
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(pair{A: 3, B: 2, D: []int{3}}))
+
+		err = Merge(&dst, map[string]interface{}{"C": 1}, WithFieldOptions("C", WithConverters(itoa)))
+		Expect(err).To(BeNil())
+		Expect(dst.C).To(Equal("1"))
+	})
+
+	Context("slice of struct", func() {
+		type env struct {
+			Name  string
+			Value string
+		}
+		type container struct {
+			Name string
+			Args []string
+			Env  []env
+		}
+		type pod struct {
+			Containers []container
+		}
+
+		var dst, src pod
+
+		BeforeEach(func() {
+			dst = pod{Containers: []container{
+				{Name: "a", Args: []string{"a"}, Env: []env{{Name: "A", Value: "a"}}},
+				{Name: "b", Args: []string{"b"}},
+			}}
+			src = pod{Containers: []container{
+				{Name: "a", Args: []string{"c"}, Env: []env{{Name: "C", Value: "c"}}},
+			}}
+		})
+
+		It("field merge func", func() {
+			calls := 0
+			err := Merge(&dst, src, WithFieldMergeFunc("Containers[*].Env", func(dst, src []env, o *Options) ([]env, error) {
+				calls++
+				Expect(o.Context().Path).To(Equal("Containers[0].Env"))
+				return append(dst, src...), nil
+			}))
+			Expect(err).To(BeNil())
+			Expect(calls).To(Equal(1))
+			Expect(dst).To(Equal(pod{Containers: []container{
+				{Name: "a", Args: []string{"c"}, Env: []env{{Name: "A", Value: "a"}, {Name: "C", Value: "c"}}},
+			}}))
+		})
+
+		It("field options", func() {
+			err := Merge(&dst, src, WithFieldOptions("Containers[*].Args", WithSliceMode(AppendSlice)))
+			Expect(err).To(BeNil())
+			Expect(dst).To(Equal(pod{Containers: []container{
+				{Name: "a", Args: []string{"a", "c"}, Env: []env{{Name: "C", Value: "c"}}},
+			}}))
+		})
+
+		It("field options in append mode", func() {
+			err := Merge(&dst, src,
+				WithSliceMode(AppendSlice),
+				WithFieldOptions("Containers[*].Name", WithoutOverwrite),
+			)
+			Expect(err).To(BeNil())
+			Expect(dst.Containers).To(gomega.HaveLen(3))
+			Expect(dst.Containers[2].Name).To(Equal("a"))
+		})
+
+		It("switches the whole slice to element by element", func() {
+			type item struct {
+				A string
+				B string
+			}
+			type list struct {
+				L []item
+			}
+			src := list{L: []item{{A: "a2"}}}
+
+			replaced := list{L: []item{{A: "a1", B: "b"}}}
+			err := Merge(&replaced, src, WithoutOverwriteWithEmptySrc)
+			Expect(err).To(BeNil())
+			Expect(replaced).To(Equal(list{L: []item{{A: "a2"}}}))
+
+			merged := list{L: []item{{A: "a1", B: "b"}}}
+			err = Merge(&merged, src, WithoutOverwriteWithEmptySrc, WithFieldOptions("L[*].A", WithoutOverwrite))
+			Expect(err).To(BeNil())
+			// B of the element is merged instead of replaced with the element
+			Expect(merged).To(Equal(list{L: []item{{A: "a1", B: "b"}}}))
+		})
+
+		It("path of errors", func() {
+			err := Merge(&dst, src, WithFieldMergeFunc("Containers[*].Name", func(dst, src string, o *Options) (string, error) {
+				return dst, errors.New("failed")
+			}))
+			Expect(err).NotTo(BeNil())
+			var mergeErr *MergeError
+			Expect(errors.As(err, &mergeErr)).To(BeTrue())
+			Expect(mergeErr.Path).To(Equal("Containers[0].Name"))
+		})
+	})
+})
+
+var _ = Describe("Merge inside custom funcs", func() {
+	type template struct {
+		Labels map[string]string
+		Image  string
+	}
+	type spec struct {
+		Replicas int
+		Template template
+	}
+
+	It("merges part of the values", func() {
+		dst := spec{
+			Replicas: 1,
+			Template: template{Labels: map[string]string{"a": "a"}, Image: "a"},
+		}
+		src := spec{
+			Replicas: 2,
+			Template: template{Labels: map[string]string{"b": "b"}, Image: "b"},
+		}
+		err := Merge(&dst, src, WithMergeFuncs(func(dst, src spec, o *Options) (spec, error) {
+			// keep replicas of dst
+			if err := o.Merge(&dst.Template, src.Template); err != nil {
+				return dst, err
+			}
+			return dst, nil
+		}))
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(spec{
+			Replicas: 1,
+			Template: template{Labels: map[string]string{"a": "a", "b": "b"}, Image: "b"},
+		}))
+	})
+
+	It("merges reflect values", func() {
+		dst := map[string]spec{"a": {Replicas: 1}}
+		src := map[string]spec{"a": {Replicas: 2, Template: template{Image: "b"}}}
+		var path string
+		err := Merge(&dst, src, WithMergeFuncs(func(dst, src spec, o *Options) (spec, error) {
+			path = o.Context().Path
+			err := o.MergeValue(reflect.ValueOf(&dst.Template).Elem(), reflect.ValueOf(src.Template))
+			return dst, err
+		}))
+		Expect(err).To(BeNil())
+		Expect(path).To(Equal("[a]"))
+		Expect(dst["a"]).To(Equal(spec{Replicas: 1, Template: template{Image: "b"}}))
+	})
 })
@@ -0,0 +1,207 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+var (
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+
+	jsonNumberConvertion = reflect.ValueOf(func(dst, src interface{}, o *Options) (interface{}, error) {
+		dstV := reflect.ValueOf(dst)
+		srcV := reflect.ValueOf(src)
+		if !o.canOverwrite(dstV, srcV) {
+			return dst, nil
+		}
+		converted := reflect.New(dstV.Type()).Elem()
+		if srcV.Type() != jsonNumberType {
+			// number to json.Number
+			converted.SetString(formatString(srcV))
+			return converted.Interface(), nil
+		}
+		num, err := parseNumber(srcV.String(), dstV.Type())
+		if err != nil {
+			return dst, fmt.Errorf("json number %v", err)
+		}
+		converted.Set(num.Convert(dstV.Type()))
+		return converted.Interface(), nil
+	})
+)
+
+// parseNumber parses the decimal number s to the number of dst kind exactly.
+// Integers are parsed without going through float64, so large IDs are kept,
+// and a number with fraction or out of range is rejected.
+func parseNumber(s string, dst reflect.Type) (reflect.Value, error) {
+	switch dst.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, dst.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(f), nil
+	}
+	// number like 10, 1e3 or 1.0
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("invalid number %q", s)
+	}
+	return ratToNumber(r, dst)
+}
+
+// ratToNumber converts the rational number r to the number of dst kind, it
+// returns an error if r is not integral or overflows for the integer dst.
+func ratToNumber(r *big.Rat, dst reflect.Type) (reflect.Value, error) {
+	var num reflect.Value
+	switch dst.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f, _ := r.Float64()
+		num = reflect.ValueOf(f)
+	default:
+		if !r.IsInt() {
+			return reflect.Value{}, fmt.Errorf("%v loses fraction in %v", r.FloatString(3), dst)
+		}
+		switch n := r.Num(); {
+		case n.IsInt64():
+			num = reflect.ValueOf(n.Int64())
+		case n.IsUint64():
+			num = reflect.ValueOf(n.Uint64())
+		default:
+			return reflect.Value{}, fmt.Errorf("%v overflows %v", n, dst)
+		}
+	}
+	if loss := numericLoss(dst, num); len(loss) > 0 {
+		return reflect.Value{}, fmt.Errorf("%v", loss)
+	}
+	return num, nil
+}
+
+// jsonNumberConvertible reports whether jsonNumberConvertion can convert src to dst
+func jsonNumberConvertible(dst, src reflect.Type) bool {
+	if src == jsonNumberType {
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return true
+		case reflect.Float32, reflect.Float64:
+			return true
+		}
+	}
+	if dst == jsonNumberType {
+		switch src.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return true
+		case reflect.Float32, reflect.Float64:
+			return true
+		}
+	}
+	return false
+}
+
+// numericLoss checks whether converting the number src to dst type loses
+// information, it returns the description of the loss, or an empty string
+// if there is no loss.
+func numericLoss(dst reflect.Type, src reflect.Value) string {
+	zero := reflect.Zero(dst)
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := src.Int()
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if zero.OverflowInt(i) {
+				return fmt.Sprintf("%v overflows %v", i, dst)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if i < 0 {
+				return fmt.Sprintf("%v loses sign in %v", i, dst)
+			}
+			if zero.OverflowUint(uint64(i)) {
+				return fmt.Sprintf("%v overflows %v", i, dst)
+			}
+		case reflect.Float32, reflect.Float64:
+			if int64(reflect.ValueOf(i).Convert(dst).Float()) != i {
+				return fmt.Sprintf("%v loses precision in %v", i, dst)
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := src.Uint()
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if u > math.MaxInt64 || zero.OverflowInt(int64(u)) {
+				return fmt.Sprintf("%v overflows %v", u, dst)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if zero.OverflowUint(u) {
+				return fmt.Sprintf("%v overflows %v", u, dst)
+			}
+		case reflect.Float32, reflect.Float64:
+			if f := reflect.ValueOf(u).Convert(dst).Float(); f >= math.MaxUint64 || uint64(f) != u {
+				return fmt.Sprintf("%v loses precision in %v", u, dst)
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		f := src.Float()
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if f != math.Trunc(f) {
+				return fmt.Sprintf("%v loses fraction in %v", f, dst)
+			}
+			if f < math.MinInt64 || f >= math.MaxInt64 || zero.OverflowInt(int64(f)) {
+				return fmt.Sprintf("%v overflows %v", f, dst)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if f != math.Trunc(f) {
+				return fmt.Sprintf("%v loses fraction in %v", f, dst)
+			}
+			if f < 0 {
+				return fmt.Sprintf("%v loses sign in %v", f, dst)
+			}
+			if f >= math.MaxUint64 || zero.OverflowUint(uint64(f)) {
+				return fmt.Sprintf("%v overflows %v", f, dst)
+			}
+		case reflect.Float32, reflect.Float64:
+			if !math.IsInf(f, 0) && zero.OverflowFloat(f) {
+				return fmt.Sprintf("%v overflows %v", f, dst)
+			}
+		}
+	}
+	return ""
+}
+
+// checkNumeric checks the loss of converting the number src to dst type, it
+// returns an error if o.StrictNumeric is true, otherwise the loss is reported.
+func (o *Options) checkNumeric(dst reflect.Type, src reflect.Value) error {
+	return o.checkLoss(numericLoss(dst, src))
+}
+
+// checkLoss returns an error for the loss if o.StrictNumeric is true, otherwise
+// the loss is reported. An empty loss means there is no loss.
+func (o *Options) checkLoss(loss string) error {
+	if len(loss) == 0 {
+		return nil
+	}
+	if o.StrictNumeric {
+		return fmt.Errorf("lossy numeric conversion: %v", loss)
+	}
+	o.reportf("lossy numeric conversion: %v", loss)
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+var _ = Describe("numeric convertion", func() {
+	DescribeTable(
+		"strict",
+		func(dst, src, expect interface{}, wantErr bool) {
+			opts.StrictNumeric = true
+			expectConvert(dst, src, expect, wantErr)
+		},
+		Entry("int64 to int8", int8(0), int64(100), int8(100), false),
+		Entry("int64 to int8 overflow", int8(0), int64(300), int8(0), true),
+		Entry("negative int to uint", uint(0), -1, uint(0), true),
+		Entry("uint64 to int64 overflow", int64(0), uint64(math.MaxUint64), int64(0), true),
+		Entry("uint16 to uint8 overflow", uint8(0), uint16(256), uint8(0), true),
+		Entry("integral float to int", 0, 2.0, 2, false),
+		Entry("fractional float to int", 0, 1.9, 0, true),
+		Entry("negative float to uint", uint(0), -1.0, uint(0), true),
+		Entry("float to int overflow", int32(0), 1e10, int32(0), true),
+		Entry("float64 to float32 overflow", float32(0), 1e300, float32(0), true),
+		Entry("large int to float64", 0.0, int64(1<<53+1), 0.0, true),
+		Entry("int to float64", 0.0, 8080, 8080.0, false),
+	)
+
+	It("returns MergeError with path", func() {
+		type config struct {
+			Port uint16
+		}
+		dst := config{}
+		err := Merge(&dst, map[string]interface{}{"Port": 65536}, WithStrictNumeric)
+		Expect(err).NotTo(BeNil())
+		var mergeErr *MergeError
+		Expect(errors.As(err, &mergeErr)).To(BeTrue())
+		Expect(mergeErr.Path).To(Equal("Port"))
+	})
+
+	It("reports lossy convertion when not strict", func() {
+		type config struct {
+			Port  int8
+			Ratio int
+			Size  int64
+		}
+		dst := config{}
+		report := &Report{}
+		err := Merge(&dst, map[string]interface{}{"Port": 300, "Ratio": 1.9, "Size": 1024}, WithReport(report))
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(config{Port: 44, Ratio: 1, Size: 1024}))
+		entries := report.Entries()
+		Expect(len(entries)).To(Equal(2))
+		paths := map[string]bool{}
+		for _, e := range entries {
+			paths[e.Path] = true
+		}
+		Expect(paths).To(Equal(map[string]bool{"Port": true, "Ratio": true}))
+	})
+})
+
+var _ = Describe("json number convertion", func() {
+	DescribeTable(
+		"",
+		expectConvert,
+		Entry("to int", 0, json.Number("8080"), 8080, false),
+		Entry("large id to int64", int64(0), json.Number("9007199254740993"), int64(9007199254740993), false),
+		Entry("max uint64", uint64(0), json.Number("18446744073709551615"), uint64(math.MaxUint64), false),
+		Entry("exponent to int", 0, json.Number("1e3"), 1000, false),
+		Entry("integral fraction to int", 0, json.Number("2.0"), 2, false),
+		Entry("fraction to int", 0, json.Number("2.5"), 0, true),
+		Entry("overflow int8", int8(0), json.Number("300"), int8(0), true),
+		Entry("negative to uint", uint(0), json.Number("-1"), uint(0), true),
+		Entry("too large", int64(0), json.Number("1e30"), int64(0), true),
+		Entry("to float", 0.0, json.Number("1.5"), 1.5, false),
+		Entry("int to json number", json.Number(""), 8080, json.Number("8080"), false),
+		Entry("float to json number", json.Number(""), 1.5, json.Number("1.5"), false),
+		Entry("integral float64 to int64", int64(0), float64(1<<40), int64(1<<40), false),
+	)
+
+	It("converts float64 decoded from json to int", func() {
+		type object struct {
+			N int
+		}
+		dst := object{N: 1}
+		Expect(Merge(&dst, map[string]interface{}{"N": 1.5})).To(BeNil())
+		Expect(dst.N).To(Equal(1))
+
+		dst = object{N: 1}
+		err := Merge(&dst, map[string]interface{}{"N": 1.5}, WithStrictNumeric)
+		Expect(err).NotTo(BeNil())
+		Expect(dst.N).To(Equal(1))
+		Expect(Merge(&dst, map[string]interface{}{"N": 2.0}, WithStrictNumeric)).To(BeNil())
+		Expect(dst.N).To(Equal(2))
+	})
+
+	It("keeps large integer ids from decoded json", func() {
+		type object struct {
+			ID    uint64
+			Count int
+		}
+		decoder := json.NewDecoder(bytes.NewBufferString(`{"ID": 18446744073709551615, "Count": 3}`))
+		decoder.UseNumber()
+		src := map[string]interface{}{}
+		Expect(decoder.Decode(&src)).To(BeNil())
+
+		dst := object{}
+		Expect(Merge(&dst, src)).To(BeNil())
+		Expect(dst).To(Equal(object{ID: math.MaxUint64, Count: 3}))
+
+		err := Merge(&dst, map[string]interface{}{"Count": json.Number("1.5")})
+		Expect(err).NotTo(BeNil())
+		Expect(Merge(&dst, map[string]interface{}{"Count": json.Number("1")}, WithoutJSONNumberConvertion)).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,113 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+var optionalType = reflect.TypeOf((*optional)(nil)).Elem()
+
+// optional is implemented by Optional[T] only
+type optional interface {
+	optional()
+}
+
+// Optional is a value with an explicit presence flag. Zero value is ambiguous,
+// e.g. a patch can not set Replicas to 0 because 0 means not set, use
+// Optional[int] instead and the merge treats it as present or absent regardless
+// of the zero-ness of Value:
+//   - if src is not set, dst is kept
+//   - if src is set, it replaces dst as a whole, even if src.Value is zero
+//     (unless Overwrite is false and dst is set)
+//
+// In JSON, Optional is encoded as its Value, and null means not set.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+}
+
+// Some returns an Optional which is set to v
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Set: true}
+}
+
+// Get returns the value and whether it is set
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set
+}
+
+// IsZero reports whether o is not set
+func (o Optional[T]) IsZero() bool {
+	return !o.Set
+}
+
+// MarshalJSON implements json.Marshaler
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	o.Value, o.Set = zero, false
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+	if err := json.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.Set = true
+	return nil
+}
+
+func (o Optional[T]) optional() {}
+
+func isOptional(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.Implements(optionalType)
+}
+
+// mergeOptional merges Optional values, the dst and src must be the same type
+func mergeOptional(dst, src reflect.Value, o *Options) error {
+	if !src.FieldByName("Set").Bool() {
+		// src is absent
+		return nil
+	}
+	if !dst.CanSet() {
+		return nil
+	}
+	if o.Overwrite || !dst.FieldByName("Set").Bool() {
+		dst.Set(src)
+	}
+	return nil
+}
+
+// wrapOptional converts the src to the type of Optional.Value and sets it as
+// the value of dst Optional, e.g. float64 decoded from JSON to Optional[int]
+func (m *porter) wrapOptional(dst, src reflect.Value, o *Options) error {
+	wrapped := reflect.New(dst.Type()).Elem()
+	if err := m.defaultMerge(wrapped.FieldByName("Value"), src, o); err != nil {
+		return err
+	}
+	wrapped.FieldByName("Set").SetBool(true)
+	return mergeOptional(dst, wrapped, o)
+}
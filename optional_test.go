@@ -0,0 +1,117 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+var _ = Describe("Optional", func() {
+	type spec struct {
+		Replicas Optional[int]  `json:"replicas"`
+		Enabled  Optional[bool] `json:"enabled"`
+		Name     string         `json:"name"`
+	}
+
+	var dst spec
+
+	BeforeEach(func() {
+		dst = spec{
+			Replicas: Some(3),
+			Enabled:  Some(true),
+			Name:     "a",
+		}
+	})
+
+	It("explicitly set to zero", func() {
+		err := Merge(&dst, spec{Replicas: Some(0)}, WithoutOverwriteWithEmptySrc)
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(spec{
+			Replicas: Some(0),
+			Enabled:  Some(true),
+			Name:     "a",
+		}))
+	})
+
+	It("absent", func() {
+		err := Merge(&dst, spec{Enabled: Some(false)})
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(spec{
+			Replicas: Some(3),
+			Enabled:  Some(false),
+			Name:     "",
+		}))
+	})
+
+	It("without overwrite", func() {
+		dst.Enabled = Optional[bool]{}
+		err := Merge(&dst, spec{Replicas: Some(1), Enabled: Some(false)}, WithoutOverwrite)
+		Expect(err).To(BeNil())
+		Expect(dst.Replicas).To(Equal(Some(3)))
+		Expect(dst.Enabled).To(Equal(Some(false)))
+	})
+
+	It("plain value", func() {
+		var replicas Optional[int]
+		err := opts.delegate.convert(reflect.ValueOf(&replicas).Elem(), reflect.ValueOf(0), opts)
+		Expect(err).To(BeNil())
+		Expect(replicas).To(Equal(Some(0)))
+	})
+
+	It("converted plain value", func() {
+		err := Merge(&dst.Replicas, int64(4))
+		Expect(err).To(BeNil())
+		Expect(dst.Replicas).To(Equal(Some(4)))
+
+		var nilReplicas *int
+		err = Merge(&dst, map[string]interface{}{"replicas": nilReplicas})
+		Expect(err).To(BeNil())
+		Expect(dst.Replicas).To(Equal(Some(4)))
+
+		err = Merge(&dst.Replicas, "a")
+		Expect(err).NotTo(BeNil())
+		Expect(dst.Replicas).To(Equal(Some(4)))
+	})
+
+	It("decoded json map", func() {
+		patch := map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(`{"replicas": 0, "enabled": false}`), &patch)).To(BeNil())
+		err := Merge(&dst, patch)
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(spec{
+			Replicas: Some(0),
+			Enabled:  Some(false),
+			Name:     "a",
+		}))
+	})
+
+	DescribeTable(
+		"json",
+		func(in string, want spec, out string) {
+			got := spec{}
+			Expect(json.Unmarshal([]byte(in), &got)).To(BeNil())
+			Expect(got).To(Equal(want))
+			data, err := json.Marshal(got)
+			Expect(err).To(BeNil())
+			Expect(string(data)).To(Equal(out))
+		},
+		Entry("absent", `{"name": "a"}`, spec{Name: "a"}, `{"replicas":null,"enabled":null,"name":"a"}`),
+		Entry("null", `{"replicas": null}`, spec{}, `{"replicas":null,"enabled":null,"name":""}`),
+		Entry("zero", `{"replicas": 0, "enabled": false}`, spec{Replicas: Some(0), Enabled: Some(false)}, `{"replicas":0,"enabled":false,"name":""}`),
+	)
+})
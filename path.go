@@ -0,0 +1,120 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	anyField = "*"
+	anyIndex = "[*]"
+)
+
+// fieldPath is the path from the root to the value being merged.
+// A struct field is a segment like "Spec", a slice index or a map key is
+// a segment like "[0]" or "[key]", and the string form looks like
+//
+// Spec.Containers[0].Env
+type fieldPath []string
+
+func parseFieldPath(in string) fieldPath {
+	ret := fieldPath{}
+	for _, field := range strings.Split(in, ".") {
+		// split "Containers[0][1]" into "Containers", "[0]", "[1]"
+		for {
+			i := strings.Index(field, "[")
+			if i < 0 {
+				break
+			}
+			if i > 0 {
+				ret = append(ret, field[:i])
+			}
+			j := strings.Index(field[i:], "]")
+			if j < 0 {
+				break
+			}
+			ret = append(ret, field[i:i+j+1])
+			field = field[i+j+1:]
+		}
+		if len(field) > 0 {
+			ret = append(ret, field)
+		}
+	}
+	return ret
+}
+
+func (p fieldPath) String() string {
+	b := strings.Builder{}
+	for i, seg := range p {
+		if i > 0 && !isIndex(seg) {
+			b.WriteString(".")
+		}
+		b.WriteString(seg)
+	}
+	return b.String()
+}
+
+func (p fieldPath) append(seg string) fieldPath {
+	ret := make(fieldPath, len(p), len(p)+1)
+	copy(ret, p)
+	return append(ret, seg)
+}
+
+// match reports whether the path matches the pattern. In pattern,
+// "*" matches any struct field and "[*]" matches any index or key.
+func (p fieldPath) match(pattern fieldPath) bool {
+	if len(p) != len(pattern) {
+		return false
+	}
+	for i := range p {
+		switch pattern[i] {
+		case anyField:
+			if isIndex(p[i]) {
+				return false
+			}
+		case anyIndex:
+			if !isIndex(p[i]) {
+				return false
+			}
+		default:
+			if pattern[i] != p[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchPrefix reports whether the path matches the leading segments of the
+// pattern, it means the pattern may match the path or the values below it.
+func (p fieldPath) matchPrefix(pattern fieldPath) bool {
+	if len(p) > len(pattern) {
+		return false
+	}
+	return p.match(pattern[:len(p)])
+}
+
+// indexSegment returns the path segment of a slice index or map key
+func indexSegment(key interface{}) string {
+	return fmt.Sprintf("[%v]", key)
+}
+
+func isIndex(seg string) bool {
+	return strings.HasPrefix(seg, "[")
+}
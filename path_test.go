@@ -0,0 +1,49 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+var _ = Describe("field path", func() {
+	DescribeTable(
+		"parse",
+		func(in string, want fieldPath) {
+			got := parseFieldPath(in)
+			Expect(got).To(Equal(want))
+			Expect(got.String()).To(Equal(in))
+		},
+		Entry("empty", "", fieldPath{}),
+		Entry("field", "Spec", fieldPath{"Spec"}),
+		Entry("nested field", "Spec.Template.Labels", fieldPath{"Spec", "Template", "Labels"}),
+		Entry("index", "Spec.Containers[0].Env", fieldPath{"Spec", "Containers", "[0]", "Env"}),
+		Entry("multiple index", "Matrix[0][1]", fieldPath{"Matrix", "[0]", "[1]"}),
+		Entry("glob", "Spec.*.Containers[*]", fieldPath{"Spec", "*", "Containers", "[*]"}),
+	)
+
+	DescribeTable(
+		"match",
+		func(path, pattern string, want bool) {
+			got := parseFieldPath(path).match(parseFieldPath(pattern))
+			Expect(got).To(Equal(want))
+		},
+		Entry("equal", "Spec.Labels", "Spec.Labels", true),
+		Entry("not equal", "Spec.Labels", "Spec.Annotations", false),
+		Entry("prefix", "Spec.Labels", "Spec", false),
+		Entry("any field", "Spec.Labels", "*.Labels", true),
+		Entry("any index", "Spec.Containers[1].Env", "Spec.Containers[*].Env", true),
+		Entry("any field does not match index", "Spec.Containers[1]", "Spec.Containers.*", false),
+		Entry("any index does not match field", "Spec.Containers.Env", "Spec.Containers[*]", false),
+	)
+})
@@ -17,15 +17,23 @@ limitations under the License.
 package gomerge
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 )
 
 var (
 	goConvertion = reflect.ValueOf(func(dst, src interface{}, o *Options) (interface{}, error) {
 		dstV := reflect.ValueOf(dst)
 		srcV := reflect.ValueOf(src)
-		if o.Overwrite || isEmptyValue(dstV) {
+		if o.canOverwrite(dstV, srcV) {
+			if !srcV.Type().ConvertibleTo(dstV.Type()) {
+				return dst, fmt.Errorf("can not convert %v to %v", srcV.Type(), dstV.Type())
+			}
+			if err := o.checkNumeric(dstV.Type(), srcV); err != nil {
+				return dst, err
+			}
 			// try to use default converter in reflect
 			converted := srcV.Convert(dstV.Type())
 			return converted.Interface(), nil
@@ -34,21 +42,76 @@ var (
 	})
 )
 
+// isZeroer is implemented by types which know whether they are zero,
+// e.g. time.Time
+type isZeroer interface {
+	IsZero() bool
+}
+
+var isZeroerType = reflect.TypeOf((*isZeroer)(nil)).Elem()
+
 type pair struct {
 	Dst reflect.Type
 	Src reflect.Type
 }
 
+type predicateMergeFunc struct {
+	match func(reflect.Type) bool
+	fn    ValueMergeFunc
+}
+
+type fieldMergeFunc struct {
+	pattern fieldPath
+	fn      reflect.Value
+}
+
 type porter struct {
-	mergeFuncs   map[reflect.Type]reflect.Value
-	convertFuncs map[pair]reflect.Value
+	emptyFuncs     map[reflect.Type]func(reflect.Value) bool
+	fieldFuncs     []fieldMergeFunc
+	mergeFuncs     map[reflect.Type]reflect.Value
+	ifaceFuncs     []reflect.Type
+	predicateFuncs []predicateMergeFunc
+	kindFuncs      map[reflect.Kind]ValueMergeFunc
+	convertFuncs   map[pair]reflect.Value
+	// stdPairs are the pairs of std converters, which are never chained by
+	// convertChain, e.g. []byte is not converted to net.IP through base64 string
+	stdPairs map[pair]bool
+	// chains caches the composed converters found by convertChain
+	chains *sync.Map
 }
 
 func newPorter() *porter {
 	return &porter{
+		emptyFuncs:   map[reflect.Type]func(reflect.Value) bool{},
 		mergeFuncs:   map[reflect.Type]reflect.Value{},
+		kindFuncs:    map[reflect.Kind]ValueMergeFunc{},
 		convertFuncs: map[pair]reflect.Value{},
+		stdPairs:     map[pair]bool{},
+		chains:       &sync.Map{},
+	}
+}
+
+func (m *porter) clone() *porter {
+	c := newPorter()
+	for k, v := range m.emptyFuncs {
+		c.emptyFuncs[k] = v
+	}
+	c.fieldFuncs = append(c.fieldFuncs, m.fieldFuncs...)
+	for k, v := range m.mergeFuncs {
+		c.mergeFuncs[k] = v
+	}
+	c.ifaceFuncs = append(c.ifaceFuncs, m.ifaceFuncs...)
+	c.predicateFuncs = append(c.predicateFuncs, m.predicateFuncs...)
+	for k, v := range m.kindFuncs {
+		c.kindFuncs[k] = v
+	}
+	for k, v := range m.convertFuncs {
+		c.convertFuncs[k] = v
 	}
+	for k, v := range m.stdPairs {
+		c.stdPairs[k] = v
+	}
+	return c
 }
 
 func (m *porter) defaultMerge(dst, src reflect.Value, o *Options) error {
@@ -62,23 +125,136 @@ func (m *porter) defaultMerge(dst, src reflect.Value, o *Options) error {
 
 func (m *porter) addCustomFuncs(fns ...interface{}) error {
 	for _, fn := range fns {
+		if fn == nil {
+			return fmt.Errorf("expected func, got nil")
+		}
 		fv := reflect.ValueOf(fn)
 		ft := fv.Type()
 		convertion, err := verifyCustomMergeFunctionSignature(ft)
 		if err != nil {
 			return err
 		}
+		dstType, srcType := customFuncTypes(ft)
 		if convertion {
-			m.convertFuncs[pair{ft.In(0), ft.In(1)}] = fv
+			m.convertFuncs[pair{dstType, srcType}] = fv
+			delete(m.stdPairs, pair{dstType, srcType})
+			// the converter graph is changed
+			m.chains = &sync.Map{}
 			continue
 		}
-		m.mergeFuncs[ft.In(0)] = fv
+		in := dstType
+		if _, ok := m.mergeFuncs[in]; !ok && in.Kind() == reflect.Interface && in.NumMethod() > 0 {
+			// the func will be applied to all types implementing the interface,
+			// keep the order of registration
+			m.ifaceFuncs = append(m.ifaceFuncs, in)
+		}
+		m.mergeFuncs[in] = fv
+	}
+	return nil
+}
+
+// addStdConverters adds the converters for the common types in standard library,
+// the converters registered before for the same types are replaced
+func (m *porter) addStdConverters() error {
+	if err := m.addCustomFuncs(stdConverters...); err != nil {
+		return err
+	}
+	for _, fn := range stdConverters {
+		dstType, srcType := customFuncTypes(reflect.TypeOf(fn))
+		m.stdPairs[pair{dstType, srcType}] = true
+	}
+	return nil
+}
+
+func (m *porter) addFieldFunc(pattern fieldPath, fn interface{}) error {
+	if fn == nil {
+		return fmt.Errorf("expected func for field %v, got nil", pattern)
+	}
+	fv := reflect.ValueOf(fn)
+	convertion, err := verifyCustomMergeFunctionSignature(fv.Type())
+	if err != nil {
+		return err
+	}
+	if convertion {
+		return fmt.Errorf("expected merge func for field %v, got convert func: %v", pattern, fv.Type())
+	}
+	m.fieldFuncs = append(m.fieldFuncs, fieldMergeFunc{pattern: pattern, fn: fv})
+	return nil
+}
+
+func (m *porter) addPredicateFunc(match func(reflect.Type) bool, fn ValueMergeFunc) error {
+	if match == nil {
+		return fmt.Errorf("expected predicate func, got nil")
+	}
+	if fn == nil {
+		return fmt.Errorf("expected merge func for predicate, got nil")
+	}
+	m.predicateFuncs = append(m.predicateFuncs, predicateMergeFunc{match: match, fn: fn})
+	return nil
+}
+
+func (m *porter) addKindFunc(kind reflect.Kind, fn ValueMergeFunc) error {
+	if fn == nil {
+		return fmt.Errorf("expected merge func for kind %v, got nil", kind)
 	}
+	m.kindFuncs[kind] = fn
 	return nil
 }
 
+// mergeFunc finds the custom merge func for the given type at the given path,
+// the precedence is path > exact type > interface > predicate > kind
+func (m *porter) mergeFunc(path fieldPath, t reflect.Type) (ValueMergeFunc, bool) {
+	var (
+		custom reflect.Value
+		ok     bool
+	)
+	for _, f := range m.fieldFuncs {
+		if dstType, _ := customFuncTypes(f.fn.Type()); dstType == t && path.match(f.pattern) {
+			custom, ok = f.fn, true
+			break
+		}
+	}
+	if !ok {
+		custom, ok = m.mergeFuncs[t]
+	}
+	if !ok {
+		for _, iface := range m.ifaceFuncs {
+			if t.Implements(iface) {
+				custom, ok = m.mergeFuncs[iface], true
+				break
+			}
+		}
+	}
+	if ok {
+		return func(dst, src reflect.Value, o *Options) (reflect.Value, error) {
+			return m.callCustom(custom, dst, src, o)
+		}, true
+	}
+	for _, p := range m.predicateFuncs {
+		if p.match(t) {
+			return p.fn, true
+		}
+	}
+	if fn, ok := m.kindFuncs[t.Kind()]; ok {
+		return fn, true
+	}
+	return nil, false
+}
+
 func (m *porter) callCustom(custom, dstV, srcV reflect.Value, o *Options) (reflect.Value, error) {
+	// the values may be interface{}, get the element behind it
+	// if the custom func does not accept interface
+	dstType, srcType := customFuncTypes(custom.Type())
+	if !dstV.Type().AssignableTo(dstType) {
+		dstV = derefInterface(dstV)
+	}
+	if !srcV.Type().AssignableTo(srcType) {
+		srcV = derefInterface(srcV)
+	}
 	args := []reflect.Value{dstV, srcV, reflect.ValueOf(o)}
+	if isContextual(custom.Type()) {
+		args = []reflect.Value{reflect.ValueOf(o.Context()), dstV, srcV}
+	}
 	rets := custom.Call(args)
 	ret0 := rets[0]
 	err := rets[1].Interface()
@@ -94,13 +270,38 @@ func (m *porter) converter(dst, src reflect.Type, o *Options) (reflect.Value, bo
 	if ok {
 		return convert, true
 	}
+	if convert, ok := m.convertChain(dst, src); ok {
+		return convert, true
+	}
+	if o.TimeConvertion && timeConvertible(dst, src) {
+		return timeConvertion, true
+	}
+	if o.TextConvertion && textConvertible(dst, src) {
+		return textConvertion, true
+	}
+	if len(o.Unit) > 0 && quantityConvertible(dst, src) {
+		return quantityConvertion, true
+	}
+	if o.JSONNumberConvertion && jsonNumberConvertible(dst, src) {
+		return jsonNumberConvertion, true
+	}
+	if o.StrConvertion && strConvertible(dst, src) {
+		return strConvertion, true
+	}
 	if o.GoConvertion && convertible(dst, src) {
 		return goConvertion, true
 	}
 	return reflect.Value{}, false
 }
 
-func (m *porter) convert(dst, src reflect.Value, o *Options) error {
+func (m *porter) convert(dst, src reflect.Value, o *Options) (err error) {
+	if o.delegate != nil && o.delegate != m {
+		// the field options at this path changed the porter, e.g. WithMergeFuncs
+		return o.delegate.convert(dst, src, o)
+	}
+	if o.RecoverPanic {
+		defer o.recoverPanic(&err)
+	}
 	// deref
 	dstType := dst.Type()
 	srcType := src.Type()
@@ -108,16 +309,50 @@ func (m *porter) convert(dst, src reflect.Value, o *Options) error {
 	srcKind := srcType.Kind()
 
 	// get true type behind interface{}
+	if srcKind == reflect.Interface {
+		srcE := derefInterface(src)
+		if !srcE.IsValid() {
+			// nil src, skip
+			return nil
+		}
+		srcType = srcE.Type()
+		srcKind = srcE.Kind()
+	}
+
 	if dstKind == reflect.Interface {
 		dstE := derefInterface(dst)
+		if !dstE.IsValid() {
+			// nil interface{}, set it directly
+			srcE := derefInterface(src)
+			if !srcType.AssignableTo(dstType) {
+				return fmt.Errorf("can not assign %v to %v", srcType, dstType)
+			}
+			if dst.CanSet() {
+				dst.Set(srcE)
+			}
+			return nil
+		}
 		dstType = dstE.Type()
 		dstKind = dstE.Kind()
 	}
 
-	if srcKind == reflect.Interface {
+	if dstType == srcType {
+		// they are the same type behind interface
 		srcE := derefInterface(src)
-		srcType = srcE.Type()
-		srcKind = srcE.Kind()
+		if dst.Kind() != reflect.Interface {
+			return m.deepMerge(dst, srcE, o)
+		}
+		// the value behind interface can not be set, merge a copy
+		// of it and set it back
+		dstECopy := reflect.New(dstType).Elem()
+		dstECopy.Set(derefInterface(dst))
+		if err := m.deepMerge(dstECopy, srcE, o); err != nil {
+			return err
+		}
+		if dst.CanSet() {
+			dst.Set(dstECopy)
+		}
+		return nil
 	}
 
 	if convert, ok := m.converter(dstType, srcType, o); ok {
@@ -128,30 +363,82 @@ func (m *porter) convert(dst, src reflect.Value, o *Options) error {
 		return directMerge(dst, converted, o)
 	}
 
+	if isOptional(dstType) && !isOptional(srcType) && dst.Kind() != reflect.Interface {
+		// merge the plain value into Optional, it becomes set
+		srcE := derefPtr(src)
+		if !srcE.IsValid() {
+			// nil src, skip
+			return nil
+		}
+		return m.wrapOptional(dst, srcE, o)
+	}
+
 	if dstKind == reflect.Ptr || srcKind == reflect.Ptr {
 		// dereference dst and src, find the element type behind ptr
 		// may be converter know how to convert int to string, but it don't know
 		// how to convert *int to string
-		dstEValue := derefPtr(dst)
-		dstEType := dstEValue.Type()
 		srcEValue := derefPtr(src)
+		if !srcEValue.IsValid() {
+			// nil src, skip
+			return nil
+		}
+		dstEValue := derefDst(dst)
+		if !dstEValue.IsValid() {
+			return fmt.Errorf("can not dereference nil %v to convert %v", dstType, srcType)
+		}
+		dstEType := derefInterface(dstEValue).Type()
 		srcEType := srcEValue.Type()
 		if dstEType != srcEType {
-			if convert, ok := m.converter(dstEType, srcEType, o); ok {
-				converted, err := m.callCustom(convert, dstEValue, srcEValue, o)
-				if err != nil {
-					return err
-				}
-				return directMerge(dstEValue, converted, o)
-			}
-			return fmt.Errorf("after dereference for <src %v, dst %v>, we still can't convert element from %v to %v type", srcType, dstType, srcEType, dstEType)
+			// the elements are not pointers any more
+			return m.convert(dstEValue, srcEValue, o)
 		}
 		return m.deepMerge(dstEValue, srcEValue, o)
 	}
+
+	if dst.Kind() == reflect.Interface {
+		// the value behind interface can not be set, convert a copy
+		// of it and set it back
+		dstECopy := reflect.New(dstType).Elem()
+		dstECopy.Set(derefInterface(dst))
+		if err := m.convert(dstECopy, src, o); err != nil {
+			return err
+		}
+		if dst.CanSet() {
+			dst.Set(dstECopy)
+		}
+		return nil
+	}
+
+	switch {
+	case dstKind == reflect.Struct && srcKind == reflect.Map:
+		return m.mapToStruct(dst, src, o)
+	case dstKind == reflect.Map && srcKind == reflect.Struct && dstType.Key().Kind() == reflect.String:
+		return m.structToMap(dst, derefInterface(src), o)
+	case (dstKind == reflect.Slice || dstKind == reflect.Array) && (srcKind == reflect.Slice || srcKind == reflect.Array):
+		return m.convertSlice(dst, derefInterface(src), o)
+	case dstKind == reflect.Map && srcKind == reflect.Map:
+		return m.convertMap(dst, derefInterface(src), o)
+	case dstKind == reflect.Slice && srcKind == reflect.String && len(o.SliceSeparator) > 0 && !isBytes(dstType):
+		return m.convertSlice(dst, splitString(derefInterface(src).String(), o.SliceSeparator), o)
+	case dstKind == reflect.String && (srcKind == reflect.Slice || srcKind == reflect.Array) && len(o.SliceSeparator) > 0 && !isBytes(srcType):
+		return m.joinSlice(dst, derefInterface(src), o)
+	case dstKind == reflect.Slice && srcKind != reflect.Slice && srcKind != reflect.Array && o.ScalarToSlice:
+		srcE := derefInterface(src)
+		wrapped := reflect.MakeSlice(reflect.SliceOf(srcE.Type()), 1, 1)
+		wrapped.Index(0).Set(srcE)
+		return m.convertSlice(dst, wrapped, o)
+	}
 	return fmt.Errorf("can not convert %v to %v", srcType, dstType)
 }
 
-func (m *porter) deepMerge(dst, src reflect.Value, o *Options) error {
+func (m *porter) deepMerge(dst, src reflect.Value, o *Options) (err error) {
+	if o.delegate != nil && o.delegate != m {
+		// the field options at this path changed the porter, e.g. WithMergeFuncs
+		return o.delegate.deepMerge(dst, src, o)
+	}
+	if o.RecoverPanic {
+		defer o.recoverPanic(&err)
+	}
 	dstType := dst.Type()
 	srcType := src.Type()
 
@@ -159,23 +446,30 @@ func (m *porter) deepMerge(dst, src reflect.Value, o *Options) error {
 		return fmt.Errorf("deepMerge: src %v and dst %v must be of same type", srcType, dstType)
 	}
 
-	if merge, ok := m.mergeFuncs[dstType]; ok {
-		merged, err := m.callCustom(merge, dst, src, o)
-		if err != nil {
+	if merge, ok := m.mergeFunc(o.path, dstType); ok {
+		merged, err := merge(dst, src, o)
+		if err == nil {
+			return directMerge(dst, merged, o)
+		}
+		if !errors.Is(err, ErrUseDefault) {
 			return err
 		}
-		return directMerge(dst, merged, o)
+		// the custom func does not handle it, fallthrough to the default merge
 	}
 
 	switch dst.Kind() {
 	case reflect.Struct:
+		if isOptional(dstType) {
+			return mergeOptional(dst, src, o)
+		}
 		if hasUnexportedField(dstType) {
 			// if the struct contains unexported field, treating it as a single entity
 			return directMerge(dst, src, o)
 		}
 		for i := 0; i < dst.NumField(); i++ {
+			o := o.atField(dst, dstType.Field(i))
 			if err := m.deepMerge(dst.Field(i), src.Field(i), o); err != nil {
-				return err
+				return o.wrapError(err)
 			}
 		}
 	case reflect.Map:
@@ -188,6 +482,7 @@ func (m *porter) deepMerge(dst, src reflect.Value, o *Options) error {
 			dst.Set(reflect.MakeMap(dstType))
 		}
 		for _, key := range src.MapKeys() {
+			o := o.at(dst, indexSegment(key))
 			srcE := derefInterface(src.MapIndex(key))
 			dstE := derefInterface(dst.MapIndex(key))
 			if !dstE.IsValid() {
@@ -198,40 +493,40 @@ func (m *porter) deepMerge(dst, src reflect.Value, o *Options) error {
 
 			srcEType := srcE.Type()
 			dstEType := dstE.Type()
-			isEmpty := isEmptyValue(dstE)
 
 			switch dstEType.Kind() {
-			case reflect.Struct, reflect.Ptr, reflect.Map:
+			case reflect.Ptr, reflect.Map:
 			default:
-				// some type can not be changed directly
-				// slice can not be set yet
-				dstE = reflect.New(dstEType).Elem()
+				// the element of map is not addressable, merge a copy
+				// of it and set it back
+				dstECopy := reflect.New(dstEType).Elem()
+				dstECopy.Set(dstE)
+				dstE = dstECopy
 			}
 
 			if dstEType != srcEType {
 				if err := m.convert(dstE, srcE, o); err != nil {
-					return err
+					return o.wrapError(err)
 				}
 			} else {
 				if err := m.deepMerge(dstE, srcE, o); err != nil {
-					return err
+					return o.wrapError(err)
 				}
 			}
 			switch dstEType.Kind() {
-			case reflect.Struct, reflect.Ptr, reflect.Map:
+			case reflect.Ptr, reflect.Map:
 			default:
-				// set it directly
-				if o.Overwrite || isEmpty {
-					dst.SetMapIndex(key, dstE)
-				}
+				dst.SetMapIndex(key, dstE)
 			}
-
 		}
 	case reflect.Slice:
 		if src.IsNil() {
 			// skip
 			return nil
 		}
+		if o.SliceMode != UniteSlice && m.hasElementRules(src, o) {
+			return m.mergeSliceElements(dst, src, o)
+		}
 		switch o.SliceMode {
 		case AppendSlice:
 			return directMerge(dst, reflect.AppendSlice(dst, src), o)
@@ -314,6 +609,50 @@ func (m *porter) deepMerge(dst, src reflect.Value, o *Options) error {
 	return nil
 }
 
+// hasElementRules reports whether a field func or field options may apply to
+// the elements of the slice or the values below them, e.g. "Containers[*].Env"
+func (m *porter) hasElementRules(slice reflect.Value, o *Options) bool {
+	for i := 0; i < slice.Len(); i++ {
+		path := o.path.append(indexSegment(i))
+		for _, f := range m.fieldFuncs {
+			if path.matchPrefix(f.pattern) {
+				return true
+			}
+		}
+		for _, f := range o.fieldOptions {
+			if path.matchPrefix(f.pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mergeSliceElements merges the elements of src into the elements of dst one by
+// one, so that the field funcs and field options at the indexes are applied.
+// In ReplaceSlice mode, the element of src is merged into the one of dst at the
+// same index, and the result has the length of src. In AppendSlice mode, the
+// elements of src are merged into zero values and appended.
+func (m *porter) mergeSliceElements(dst, src reflect.Value, o *Options) error {
+	if !o.canOverwrite(dst, src) {
+		return nil
+	}
+	offset := 0
+	if o.SliceMode == AppendSlice {
+		offset = dst.Len()
+	}
+	merged := reflect.MakeSlice(dst.Type(), offset+src.Len(), offset+src.Len())
+	reflect.Copy(merged, dst)
+	for i := 0; i < src.Len(); i++ {
+		eo := o.at(dst, indexSegment(offset+i))
+		if err := m.defaultMerge(merged.Index(offset+i), src.Index(i), eo); err != nil {
+			return eo.wrapError(err)
+		}
+	}
+	dst.Set(merged)
+	return nil
+}
+
 // Verifies whether a conversion function has a correct signature.
 func verifyCustomMergeFunctionSignature(ft reflect.Type) (convertion bool, err error) {
 	if ft.Kind() != reflect.Func {
@@ -328,17 +667,20 @@ func verifyCustomMergeFunctionSignature(ft reflect.Type) (convertion bool, err e
 		err = fmt.Errorf("expected two 'out' param, got: %v", ft)
 		return
 	}
-	if ft.In(0) != ft.In(1) {
+	dstType, srcType := customFuncTypes(ft)
+	if dstType != srcType {
 		convertion = true
 	}
-	if ft.In(0) != ft.Out(0) {
-		err = fmt.Errorf("expected 'in' param 0 and 'out' param 0 must be the same type, got <%v, %v>", ft.In(0), ft.Out(0))
+	if dstType != ft.Out(0) {
+		err = fmt.Errorf("expected dst param and 'out' param 0 must be the same type, got <%v, %v>", dstType, ft.Out(0))
 		return
 	}
-	opts := &Options{}
-	if e, a := reflect.TypeOf(opts), ft.In(2); e != a {
-		err = fmt.Errorf("expected '%v' arg for 'in' param 2, got '%v' (%v)", e, a, ft)
-		return
+	if !isContextual(ft) {
+		opts := &Options{}
+		if e, a := reflect.TypeOf(opts), ft.In(2); e != a {
+			err = fmt.Errorf("expected '%v' arg for 'in' param 2, got '%v' (%v)", e, a, ft)
+			return
+		}
 	}
 	var forErrorType error
 	// This convolution is necessary, otherwise TypeOf picks up on the fact
@@ -351,6 +693,20 @@ func verifyCustomMergeFunctionSignature(ft reflect.Type) (convertion bool, err e
 	return
 }
 
+// isContextual reports whether the custom func takes *MergeContext as the
+// first param
+func isContextual(ft reflect.Type) bool {
+	return ft.In(0) == mergeContextType
+}
+
+// customFuncTypes returns the dst and src types of custom func
+func customFuncTypes(ft reflect.Type) (dst, src reflect.Type) {
+	if isContextual(ft) {
+		return ft.In(1), ft.In(2)
+	}
+	return ft.In(0), ft.In(1)
+}
+
 // directMerge treats dst and src as single entity and use dst.Set(src)
 // to merge them directly
 // the dst and src must be the same type
@@ -374,7 +730,7 @@ func directMerge(dst, src reflect.Value, o *Options) error {
 		// can not set
 		return nil
 	}
-	if o.Overwrite || isEmptyValue(dstE) {
+	if o.canOverwrite(dstE, srcE) {
 		// if overwrite or element behind dst is empty (ingore interface{})
 		dst.Set(srcE)
 	}
@@ -445,8 +801,44 @@ func derefInterface(in reflect.Value) reflect.Value {
 	return in
 }
 
+// derefDst dereferences the pointers behind dst and allocates the nil pointers
+// which can be set. It stops at the interface holding a non-pointer value,
+// because the value behind interface can not be set. An invalid value is
+// returned if a nil pointer can not be allocated.
+func derefDst(in reflect.Value) reflect.Value {
+	for {
+		switch in.Kind() {
+		case reflect.Ptr:
+			if in.IsNil() {
+				if !in.CanSet() {
+					return reflect.Value{}
+				}
+				in.Set(reflect.New(in.Type().Elem()))
+			}
+			in = in.Elem()
+		case reflect.Interface:
+			if in.IsNil() {
+				return in
+			}
+			elem := in.Elem()
+			if elem.Kind() != reflect.Ptr {
+				return in
+			}
+			if elem.IsNil() {
+				if !in.CanSet() {
+					return reflect.Value{}
+				}
+				elem = reflect.New(elem.Type().Elem())
+				in.Set(elem)
+			}
+			in = elem
+		default:
+			return in
+		}
+	}
+}
+
 func derefPtr(in reflect.Value) reflect.Value {
-	// TODO:
 	for in.Kind() == reflect.Ptr || in.Kind() == reflect.Interface {
 		in = in.Elem()
 	}
@@ -464,9 +856,45 @@ func hasUnexportedField(tpy reflect.Type) bool {
 	return false
 }
 
-// copy from encoding/json
+// canOverwrite reports whether dst can be overwritten by src, dst can be
+// overwritten if it is empty, or Overwrite is true and src is not empty
+// unless OverwriteWithEmptySrc is true
+func (o *Options) canOverwrite(dst, src reflect.Value) bool {
+	if o.isEmpty(dst) {
+		return true
+	}
+	return o.Overwrite && (o.OverwriteWithEmptySrc || !o.isEmpty(src))
+}
+
+// isEmpty reports whether v is empty, the precedence is
+// custom empty func (WithEmptyFunc) > IsZero() method > isEmptyValue
+func (o *Options) isEmpty(v reflect.Value) bool {
+	if fn, ok := o.delegate.emptyFuncs[v.Type()]; ok {
+		return fn(v)
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return true
+		}
+	}
+	if !v.CanInterface() {
+		return isEmptyValue(v)
+	}
+	if v.Type().Implements(isZeroerType) {
+		return v.Interface().(isZeroer).IsZero()
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(isZeroerType) {
+		return v.Addr().Interface().(isZeroer).IsZero()
+	}
+	return isEmptyValue(v)
+}
+
+// copy from encoding/json, and the struct whose fields are all zero is empty
 func isEmptyValue(v reflect.Value) bool {
 	switch v.Kind() {
+	case reflect.Struct:
+		return v.IsZero()
 	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
 		return v.Len() == 0
 	case reflect.Bool:
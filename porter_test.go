@@ -17,9 +17,12 @@ limitations under the License.
 package gomerge
 
 import (
+	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/ginkgo/config"
@@ -56,16 +59,44 @@ var (
 	BeTrue         = gomega.BeTrue
 )
 
+type testEnum int
+
+func (e testEnum) String() string {
+	return strconv.Itoa(int(e))
+}
+
 var (
 	p    *porter
 	opts *Options
 )
 
 var _ = BeforeEach(func() {
-	p = newPorter()
 	opts = newOptions()
+	p = opts.delegate
 })
 
+// expectConvert converts src to a copy of dst by p with opts, and expects the
+// result equals to expect, or an error if wantErr is true
+func expectConvert(dst, src, expect interface{}, wantErr bool) {
+	dstV := reflect.New(reflect.TypeOf(dst)).Elem()
+	dstV.Set(reflect.ValueOf(dst))
+	err := p.convert(dstV, reflect.ValueOf(src), opts)
+	if wantErr {
+		Expect(err).NotTo(BeNil())
+		return
+	}
+	Expect(err).To(BeNil())
+	Expect(dstV.Interface()).To(Equal(expect))
+}
+
+// expectConvertWith is like expectConvert, but applies the option f first
+func expectConvertWith(dst, src, expect interface{}, f func(*Options), wantErr bool) {
+	if f != nil {
+		f(opts)
+	}
+	expectConvert(dst, src, expect, wantErr)
+}
+
 var _ = Describe("add custom funcs", func() {
 	AfterEach(func() {
 		p = newPorter()
@@ -154,6 +185,120 @@ var _ = Describe("custom merge function", func() {
 	})
 })
 
+var _ = Describe("kind and predicate merge function", func() {
+	type hostname string
+	type port string
+
+	upper := func(dst, src reflect.Value, o *Options) (reflect.Value, error) {
+		return reflect.ValueOf(strings.ToUpper(src.String())).Convert(dst.Type()), nil
+	}
+	suffix := func(dst, src reflect.Value, o *Options) (reflect.Value, error) {
+		return reflect.ValueOf(src.String() + ".local").Convert(dst.Type()), nil
+	}
+	isHostname := func(t reflect.Type) bool {
+		return t == reflect.TypeOf(hostname(""))
+	}
+
+	BeforeEach(func() {
+		Expect(p.addKindFunc(reflect.String, upper)).To(BeNil())
+		Expect(p.addPredicateFunc(isHostname, suffix)).To(BeNil())
+		p.addCustomFuncs(func(dst, src string, o *Options) (string, error) {
+			return dst + src, nil
+		})
+	})
+	AfterEach(func() {
+		p = newPorter()
+	})
+
+	It("exact type", func() {
+		dst := "a"
+		p.deepMerge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf("b"), opts)
+		Expect(dst).To(Equal("ab"))
+	})
+	It("predicate", func() {
+		dst := hostname("a")
+		p.deepMerge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(hostname("b")), opts)
+		Expect(dst).To(Equal(hostname("b.local")))
+	})
+	It("kind", func() {
+		dst := port("a")
+		p.deepMerge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(port("b")), opts)
+		Expect(dst).To(Equal(port("B")))
+	})
+	It("built-in", func() {
+		dst := 1
+		p.deepMerge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(2), opts)
+		Expect(dst).To(Equal(2))
+	})
+})
+
+var _ = Describe("interface merge function", func() {
+	type plain int
+
+	BeforeEach(func() {
+		p.addCustomFuncs(func(dst, src fmt.Stringer, o *Options) (fmt.Stringer, error) {
+			return dst.(testEnum) * src.(testEnum), nil
+		})
+	})
+	AfterEach(func() {
+		p = newPorter()
+	})
+	It("implements the interface", func() {
+		dst := testEnum(2)
+		p.deepMerge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(testEnum(3)), opts)
+		Expect(dst).To(Equal(testEnum(6)))
+	})
+	It("exact type takes precedence", func() {
+		p.addCustomFuncs(func(dst, src testEnum, o *Options) (testEnum, error) {
+			return dst + src, nil
+		})
+		dst := testEnum(2)
+		p.deepMerge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(testEnum(3)), opts)
+		Expect(dst).To(Equal(testEnum(5)))
+	})
+	It("does not implement the interface", func() {
+		dst := plain(2)
+		p.deepMerge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(plain(3)), opts)
+		Expect(dst).To(Equal(plain(3)))
+	})
+})
+
+var _ = Describe("fallback to default merge", func() {
+	type test struct {
+		A string
+		B int
+	}
+	var called int
+
+	BeforeEach(func() {
+		called = 0
+		p.addCustomFuncs(func(dst, src test, o *Options) (test, error) {
+			called++
+			if src.A == "special" {
+				return test{A: "handled"}, nil
+			}
+			return dst, fmt.Errorf("skip: %w", ErrUseDefault)
+		})
+	})
+	AfterEach(func() {
+		p = newPorter()
+	})
+	It("handled by custom func", func() {
+		dst := test{A: "1", B: 1}
+		err := p.deepMerge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(test{A: "special"}), opts)
+		Expect(err).To(BeNil())
+		Expect(called).To(Equal(1))
+		Expect(dst).To(Equal(test{A: "handled"}))
+	})
+	It("use default", func() {
+		dst := test{A: "1", B: 1}
+		err := p.deepMerge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(test{A: "2", B: 2}), opts)
+		Expect(err).To(BeNil())
+		Expect(called).To(Equal(1))
+		Expect(dst).To(Equal(test{A: "2", B: 2}))
+	})
+})
+
 var _ = Describe("convert", func() {
 	Context("with go convertion", func() {
 		BeforeEach(func() {
@@ -202,6 +347,24 @@ var _ = Describe("convert", func() {
 			Expect(dst).To(Equal(2))
 		})
 	})
+	Context("does not panic", func() {
+		It("nil src ptr", func() {
+			dst := 1
+			var src *int32
+			err := p.convert(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(src), opts)
+			Expect(err).To(BeNil())
+			Expect(dst).To(Equal(1))
+		})
+		It("nil dst ptr", func() {
+			var dst *int
+			err := p.convert(reflect.ValueOf(dst), reflect.ValueOf(int32(2)), opts)
+			Expect(err).NotTo(BeNil())
+		})
+		It("inconvertible types", func() {
+			_, err := p.callCustom(goConvertion, reflect.ValueOf(1), reflect.ValueOf("2"), opts)
+			Expect(err).NotTo(BeNil())
+		})
+	})
 	// Context("without overwrite", func() {
 	// 	BeforeEach(func() {
 	// 		opts.Overwrite = false
@@ -591,6 +754,42 @@ var _ = Describe("deep merge map[string]string", func() {
 	})
 })
 
+var _ = Describe("deep merge map[string]struct", func() {
+	type value struct {
+		A int
+		B string
+	}
+
+	DescribeTable(
+		"",
+		func(f func(*Options), expect map[string]value) {
+			if f != nil {
+				f(opts)
+			}
+			dst := map[string]value{"a": {A: 1, B: "b"}, "b": {A: 1}}
+			src := map[string]value{"a": {A: 2}, "c": {A: 3}}
+			err := p.deepMerge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(src), opts)
+			Expect(err).To(BeNil())
+			Expect(dst).To(Equal(expect))
+		},
+		// the struct in map is merged field by field and set back to the map
+		Entry("with overwrite", nil,
+			map[string]value{"a": {A: 2, B: ""}, "b": {A: 1}, "c": {A: 3}}),
+		Entry("without overwrite with empty src", WithoutOverwriteWithEmptySrc,
+			map[string]value{"a": {A: 2, B: "b"}, "b": {A: 1}, "c": {A: 3}}),
+		Entry("without overwrite", WithoutOverwrite,
+			map[string]value{"a": {A: 1, B: "b"}, "b": {A: 1}, "c": {A: 3}}),
+	)
+
+	It("fills the empty fields without overwrite", func() {
+		dst := map[string]value{"a": {A: 1}}
+		src := map[string]value{"a": {A: 2, B: "b"}}
+		err := p.deepMerge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(src), opts.clone(WithoutOverwrite))
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(map[string]value{"a": {A: 1, B: "b"}}))
+	})
+})
+
 var _ = Describe("deep merge map[string]interface{}", func() {
 
 	Context("with overwrite", func() {
@@ -911,6 +1110,22 @@ var _ = Describe("verifyCustomMergeFunctionSignature", func() {
 			true,
 			false,
 		),
+		Entry(
+			"merge int with context",
+			func(ctx *MergeContext, d, s int) (int, error) {
+				return 0, nil
+			},
+			false,
+			false,
+		),
+		Entry(
+			"int to string with context",
+			func(ctx *MergeContext, d string, s int) (string, error) {
+				return "", nil
+			},
+			true,
+			false,
+		),
 		Entry(
 			"error",
 			func(d string, s int, o *Options) (int, error) {
@@ -941,6 +1156,48 @@ var _ = Describe("hasUnexportedField", func() {
 	)
 })
 
+type ptrZeroer struct {
+	Value int
+}
+
+func (z *ptrZeroer) IsZero() bool {
+	return z.Value < 0
+}
+
+var _ = Describe("isEmpty", func() {
+	type export struct {
+		A string
+	}
+	type hostname string
+
+	DescribeTable(
+		"",
+		func(in interface{}, want bool) {
+			v := reflect.New(reflect.TypeOf(in)).Elem()
+			v.Set(reflect.ValueOf(in))
+			Expect(opts.isEmpty(v)).To(Equal(want))
+		},
+		Entry("empty string", "", true),
+		Entry("string", "1", false),
+		Entry("zero struct", export{}, true),
+		Entry("struct", export{A: "1"}, false),
+		Entry("zero time", time.Time{}, true),
+		Entry("time", time.Unix(1, 0), false),
+		Entry("IsZero with ptr receiver", ptrZeroer{Value: -1}, true),
+		Entry("not zero with ptr receiver", ptrZeroer{Value: 0}, false),
+		Entry("nil ptr", (*time.Time)(nil), true),
+	)
+
+	It("custom empty func", func() {
+		opts = opts.clone(WithEmptyFunc(reflect.TypeOf(hostname("")), func(v reflect.Value) bool {
+			return v.String() == "localhost"
+		}))
+		Expect(opts.isEmpty(reflect.ValueOf(hostname("localhost")))).To(BeTrue())
+		Expect(opts.isEmpty(reflect.ValueOf(hostname("")))).NotTo(BeTrue())
+		Expect(opts.isEmpty(reflect.ValueOf(""))).To(BeTrue())
+	})
+})
+
 var _ = Describe("convertible", func() {
 	type temp string
 	type tempInt int
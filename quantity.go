@@ -0,0 +1,121 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// The units of quantity, see WithUnit
+const (
+	// UnitBytes parses byte sizes with binary suffixes like "512Mi", "1GiB"
+	// and decimal suffixes like "1GB", "100k"
+	UnitBytes = "bytes"
+	// UnitPercent parses percentages like "50%" to 50
+	UnitPercent = "percent"
+	// UnitRatio parses percentages like "50%" to 0.5
+	UnitRatio = "ratio"
+)
+
+var (
+	quantityConvertion = reflect.ValueOf(func(dst, src interface{}, o *Options) (interface{}, error) {
+		dstV := reflect.ValueOf(dst)
+		srcV := reflect.ValueOf(src)
+		if !o.canOverwrite(dstV, srcV) {
+			return dst, nil
+		}
+		r, err := parseQuantity(srcV.String(), o.Unit)
+		if err != nil {
+			return dst, err
+		}
+		num, err := ratToNumber(r, dstV.Type())
+		if err != nil {
+			return dst, fmt.Errorf("quantity %q: %v", srcV.String(), err)
+		}
+		converted := reflect.New(dstV.Type()).Elem()
+		converted.Set(num.Convert(dstV.Type()))
+		return converted.Interface(), nil
+	})
+
+	// byteSuffixes are ordered so that the longer suffix is matched first
+	byteSuffixes = []struct {
+		suffix string
+		scale  int64
+	}{
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40}, {"PiB", 1 << 50}, {"EiB", 1 << 60},
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40}, {"Pi", 1 << 50}, {"Ei", 1 << 60},
+		{"KB", 1e3}, {"kB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12}, {"PB", 1e15}, {"EB", 1e18},
+		{"K", 1e3}, {"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12}, {"P", 1e15}, {"E", 1e18},
+		{"B", 1},
+	}
+)
+
+// parseQuantity parses the quantity string s in the unit exactly
+func parseQuantity(s, unit string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	num := s
+	scale := big.NewRat(1, 1)
+	switch unit {
+	case UnitBytes:
+		for _, b := range byteSuffixes {
+			if strings.HasSuffix(s, b.suffix) {
+				num = strings.TrimSpace(strings.TrimSuffix(s, b.suffix))
+				scale.SetInt64(b.scale)
+				break
+			}
+		}
+	case UnitPercent, UnitRatio:
+		hasPercent := strings.HasSuffix(s, "%")
+		num = strings.TrimSpace(strings.TrimSuffix(s, "%"))
+		if unit == UnitRatio && hasPercent {
+			scale.SetFrac64(1, 100)
+		}
+	default:
+		return nil, fmt.Errorf("unknown unit %q", unit)
+	}
+	r, ok := new(big.Rat).SetString(num)
+	if !ok || strings.Contains(num, "/") {
+		return nil, fmt.Errorf("invalid %v quantity %q", unit, s)
+	}
+	return r.Mul(r, scale), nil
+}
+
+// isUnit reports whether the unit is known
+func isUnit(unit string) bool {
+	switch unit {
+	case UnitBytes, UnitPercent, UnitRatio:
+		return true
+	}
+	return false
+}
+
+// quantityConvertible reports whether quantityConvertion can convert src to dst
+func quantityConvertible(dst, src reflect.Type) bool {
+	if src.Kind() != reflect.String {
+		return false
+	}
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	case reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+var _ = Describe("quantity convertion", func() {
+	DescribeTable(
+		"",
+		func(unit string, dst, src, expect interface{}, wantErr bool) {
+			opts.Unit = unit
+			expectConvert(dst, src, expect, wantErr)
+		},
+		Entry("binary bytes", UnitBytes, int64(0), "512Mi", int64(512<<20), false),
+		Entry("binary bytes with B", UnitBytes, int64(0), "1GiB", int64(1<<30), false),
+		Entry("decimal bytes", UnitBytes, uint64(0), "1GB", uint64(1e9), false),
+		Entry("fractional bytes", UnitBytes, int64(0), "1.5Ki", int64(1536), false),
+		Entry("plain bytes", UnitBytes, int64(0), "100", int64(100), false),
+		Entry("bytes to float", UnitBytes, 0.0, "1k", 1000.0, false),
+		Entry("bytes with fraction left", UnitBytes, int64(0), "1.5B", int64(0), true),
+		Entry("bytes overflow", UnitBytes, int32(0), "4Gi", int32(0), true),
+		Entry("negative bytes to uint", UnitBytes, uint64(0), "-1Mi", uint64(0), true),
+		Entry("invalid bytes", UnitBytes, int64(0), "1XB", int64(0), true),
+		Entry("percent", UnitPercent, int64(0), "50%", int64(50), false),
+		Entry("percent to float", UnitPercent, 0.0, "12.5%", 12.5, false),
+		Entry("percent without sign", UnitPercent, int64(0), "50", int64(50), false),
+		Entry("ratio", UnitRatio, 0.0, "50%", 0.5, false),
+		Entry("ratio without sign", UnitRatio, 0.0, "0.25", 0.25, false),
+		Entry("unknown unit", "meters", int64(0), "1m", int64(0), true),
+		Entry("no unit", "", int64(0), "1Mi", int64(0), true),
+	)
+
+	It("uses the unit in tag", func() {
+		type resources struct {
+			Memory  int64             `merge:"unit=bytes"`
+			CPU     float64           `json:"cpu,unit=ratio"`
+			Limits  map[string]uint64 `merge:"limits,unit=bytes"`
+			Replica int
+		}
+		dst := resources{}
+		err := Merge(&dst, map[string]interface{}{
+			"Memory": "512Mi",
+			"cpu":    "50%",
+			"limits": map[string]interface{}{"disk": "1Gi"},
+		})
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(resources{
+			Memory: 512 << 20,
+			CPU:    0.5,
+			Limits: map[string]uint64{"disk": 1 << 30},
+		}))
+
+		err = Merge(&dst, map[string]interface{}{"Replica": "1Mi"})
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("uses the unit in field options", func() {
+		type config struct {
+			Size int64
+		}
+		dst := config{}
+		err := Merge(&dst, map[string]interface{}{"Size": "2Ki"}, WithFieldOptions("Size", WithUnit(UnitBytes)))
+		Expect(err).To(BeNil())
+		Expect(dst.Size).To(Equal(int64(2048)))
+
+		err = Merge(&dst, map[string]interface{}{"Size": "2Ki"}, WithUnit("meters"))
+		Expect(err).NotTo(BeNil())
+	})
+})
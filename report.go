@@ -0,0 +1,67 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Report collects the notable events during merging which are not errors,
+// e.g. lossy numeric conversions. It is safe for concurrent use.
+type Report struct {
+	mu      sync.Mutex
+	entries []ReportEntry
+}
+
+// ReportEntry is an event in Report
+type ReportEntry struct {
+	// Path is the path of the value from the target, e.g. Spec.Replicas
+	Path    string
+	Message string
+}
+
+func (e ReportEntry) String() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%v: %v", e.Path, e.Message)
+}
+
+// Entries returns a copy of all entries in the report
+func (r *Report) Entries() []ReportEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ReportEntry(nil), r.entries...)
+}
+
+func (r *Report) add(entry ReportEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// reportf adds an entry with the current path to the report if there is one
+func (o *Options) reportf(format string, args ...interface{}) {
+	if o.Report == nil {
+		return
+	}
+	o.Report.add(ReportEntry{
+		Path:    o.path.String(),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+type float interface {
+	~float32 | ~float64
+}
+
+// stdConverters are the converters for the common types in standard library,
+// see WithStdConverters. They are built once and shared by all porters.
+var stdConverters = newStdConverters()
+
+func newStdConverters() []interface{} {
+	bigs := []interface{}{
+		stringToBigInt,
+		jsonNumberToBigInt,
+		integerToBigInt[int], integerToBigInt[int8], integerToBigInt[int16], integerToBigInt[int32], integerToBigInt[int64],
+		integerToBigInt[uint], integerToBigInt[uint8], integerToBigInt[uint16], integerToBigInt[uint32], integerToBigInt[uint64],
+		floatToBigInt[float32], floatToBigInt[float64],
+		stringToBigFloat,
+		jsonNumberToBigFloat,
+		integerToBigFloat[int], integerToBigFloat[int8], integerToBigFloat[int16], integerToBigFloat[int32], integerToBigFloat[int64],
+		integerToBigFloat[uint], integerToBigFloat[uint8], integerToBigFloat[uint16], integerToBigFloat[uint32], integerToBigFloat[uint64],
+		floatToBigFloat[float32], floatToBigFloat[float64],
+	}
+	ret := []interface{}{
+		stringToIP,
+		stringToIPNet,
+		stringToURL,
+		stringToRegexp,
+		stringToBytes,
+		bytesToString,
+	}
+	ret = append(ret, bigs...)
+	for _, fn := range bigs {
+		// big.Int and big.Float can also be used as values
+		ret = append(ret, elemConverter(fn))
+	}
+	return ret
+}
+
+// elemConverter adapts the converter to *T into the converter to T, e.g.
+// func(dst *big.Int, src int, o *Options) (*big.Int, error) into
+// func(dst big.Int, src int, o *Options) (big.Int, error)
+func elemConverter(fn interface{}) interface{} {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	elem := ft.Out(0).Elem()
+	in := []reflect.Type{elem, ft.In(1), ft.In(2)}
+	out := []reflect.Type{elem, ft.Out(1)}
+	return reflect.MakeFunc(reflect.FuncOf(in, out, false), func(args []reflect.Value) []reflect.Value {
+		dst := reflect.New(elem)
+		dst.Elem().Set(args[0])
+		rets := fv.Call([]reflect.Value{dst, args[1], args[2]})
+		if !rets[1].IsNil() {
+			return []reflect.Value{args[0], rets[1]}
+		}
+		return []reflect.Value{rets[0].Elem(), rets[1]}
+	}).Interface()
+}
+
+func stringToIP(dst net.IP, src string, o *Options) (net.IP, error) {
+	ip := net.ParseIP(strings.TrimSpace(src))
+	if ip == nil {
+		return dst, fmt.Errorf("invalid IP address %q", src)
+	}
+	return ip, nil
+}
+
+// stringToIPNet parses CIDR like "192.168.0.0/16", the IP in CIDR is masked
+func stringToIPNet(dst *net.IPNet, src string, o *Options) (*net.IPNet, error) {
+	_, ipNet, err := net.ParseCIDR(strings.TrimSpace(src))
+	if err != nil {
+		return dst, err
+	}
+	return ipNet, nil
+}
+
+func stringToURL(dst *url.URL, src string, o *Options) (*url.URL, error) {
+	return url.Parse(src)
+}
+
+func stringToRegexp(dst *regexp.Regexp, src string, o *Options) (*regexp.Regexp, error) {
+	return regexp.Compile(src)
+}
+
+// stringToBytes decodes the base64 string like encoding/json
+func stringToBytes(dst []byte, src string, o *Options) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(src)
+}
+
+// bytesToString encodes the bytes to base64 string like encoding/json
+func bytesToString(dst string, src []byte, o *Options) (string, error) {
+	return base64.StdEncoding.EncodeToString(src), nil
+}
+
+// stringToBigInt parses the integer with base prefix like "0x" or "0b", or
+// the integral number like "1e3"
+func stringToBigInt(dst *big.Int, src string, o *Options) (*big.Int, error) {
+	s := strings.TrimSpace(src)
+	if i, ok := new(big.Int).SetString(s, 0); ok {
+		return i, nil
+	}
+	if r, ok := new(big.Rat).SetString(s); ok && r.IsInt() && !strings.Contains(s, "/") {
+		return r.Num(), nil
+	}
+	return dst, fmt.Errorf("invalid integer %q", src)
+}
+
+func jsonNumberToBigInt(dst *big.Int, src json.Number, o *Options) (*big.Int, error) {
+	return stringToBigInt(dst, string(src), o)
+}
+
+func integerToBigInt[T integer](dst *big.Int, src T, o *Options) (*big.Int, error) {
+	if src < 0 {
+		return big.NewInt(int64(src)), nil
+	}
+	return new(big.Int).SetUint64(uint64(src)), nil
+}
+
+// floatToBigInt converts the integral float to big.Int, the float with fraction
+// is rejected
+func floatToBigInt[T float](dst *big.Int, src T, o *Options) (*big.Int, error) {
+	f := float64(src)
+	if math.IsNaN(f) || math.IsInf(f, 0) || f != math.Trunc(f) {
+		return dst, fmt.Errorf("%v is not an integer", f)
+	}
+	i, _ := big.NewFloat(f).Int(nil)
+	return i, nil
+}
+
+func stringToBigFloat(dst *big.Float, src string, o *Options) (*big.Float, error) {
+	f, ok := new(big.Float).SetString(strings.TrimSpace(src))
+	if !ok {
+		return dst, fmt.Errorf("invalid float %q", src)
+	}
+	return f, nil
+}
+
+func jsonNumberToBigFloat(dst *big.Float, src json.Number, o *Options) (*big.Float, error) {
+	return stringToBigFloat(dst, string(src), o)
+}
+
+func integerToBigFloat[T integer](dst *big.Float, src T, o *Options) (*big.Float, error) {
+	if src < 0 {
+		return new(big.Float).SetInt64(int64(src)), nil
+	}
+	return new(big.Float).SetUint64(uint64(src)), nil
+}
+
+func floatToBigFloat[T float](dst *big.Float, src T, o *Options) (*big.Float, error) {
+	f := float64(src)
+	if math.IsNaN(f) {
+		return dst, fmt.Errorf("can not convert NaN to %T", dst)
+	}
+	return big.NewFloat(f), nil
+}
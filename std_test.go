@@ -0,0 +1,151 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+)
+
+var _ = Describe("std converters", func() {
+	BeforeEach(func() {
+		Expect(p.addStdConverters()).To(BeNil())
+	})
+
+	DescribeTable(
+		"",
+		expectConvert,
+		Entry("string to IP", net.IP(nil), "10.0.0.1", net.ParseIP("10.0.0.1"), false),
+		Entry("invalid IP", net.IP(nil), "10.0.0", nil, true),
+		Entry("string to IPNet", (*net.IPNet)(nil), "10.1.2.3/8", &net.IPNet{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}, false),
+		Entry("invalid IPNet", (*net.IPNet)(nil), "10.1.2.3", nil, true),
+		Entry("string to URL", (*url.URL)(nil), "https://example.com/a", &url.URL{Scheme: "https", Host: "example.com", Path: "/a"}, false),
+		Entry("string to Regexp", (*regexp.Regexp)(nil), "^a+$", regexp.MustCompile("^a+$"), false),
+		Entry("invalid Regexp", (*regexp.Regexp)(nil), "(", nil, true),
+		Entry("base64 string to bytes", []byte(nil), "aGVsbG8=", []byte("hello"), false),
+		Entry("invalid base64", []byte(nil), "!", nil, true),
+		Entry("bytes to base64 string", "", []byte("hello"), "aGVsbG8=", false),
+		Entry("string to big.Int", (*big.Int)(nil), "0x10", big.NewInt(16), false),
+		Entry("exponent to big.Int", (*big.Int)(nil), "1e3", big.NewInt(1000), false),
+		Entry("invalid big.Int", (*big.Int)(nil), "1.5", nil, true),
+		Entry("json.Number to big.Int", (*big.Int)(nil), json.Number("123"), big.NewInt(123), false),
+		Entry("int to big.Int", (*big.Int)(nil), -1, big.NewInt(-1), false),
+		Entry("uint64 to big.Int", (*big.Int)(nil), uint64(1<<63), new(big.Int).SetUint64(1<<63), false),
+		Entry("integral float to big.Int", (*big.Int)(nil), 1e20, new(big.Int).Mul(big.NewInt(1e10), big.NewInt(1e10)), false),
+		Entry("fractional float to big.Int", (*big.Int)(nil), 1.5, nil, true),
+	)
+
+	DescribeTable(
+		"big.Float",
+		func(src interface{}, expect float64) {
+			dst := (*big.Float)(nil)
+			dstV := reflect.ValueOf(&dst).Elem()
+			err := p.convert(dstV, reflect.ValueOf(src), opts)
+			Expect(err).To(BeNil())
+			Expect(dst.Cmp(big.NewFloat(expect))).To(Equal(0))
+		},
+		Entry("from string", "1.5", 1.5),
+		Entry("from json.Number", json.Number("-3"), -3.0),
+		Entry("from int", 2, 2.0),
+		Entry("from float", 2.5, 2.5),
+	)
+
+	It("is not chained", func() {
+		// []byte to string and string to *url.URL
+		_, ok := p.convertChain(reflect.TypeOf((*url.URL)(nil)), reflect.TypeOf([]byte(nil)))
+		Expect(ok).NotTo(BeTrue())
+		_, ok = p.convertChain(reflect.TypeOf((*big.Int)(nil)), reflect.TypeOf([]byte(nil)))
+		Expect(ok).NotTo(BeTrue())
+	})
+
+	It("can be overridden", func() {
+		dst := ""
+		err := Merge(&dst, []byte("hello"), WithStdConverters, WithConverters(func(dst string, src []byte, o *Options) (string, error) {
+			return string(src), nil
+		}))
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal("hello"))
+	})
+
+	It("merges into top-level targets", func() {
+		var bi big.Int
+		Expect(Merge(&bi, 5, WithStdConverters)).To(BeNil())
+		Expect(bi.Int64()).To(Equal(int64(5)))
+
+		var bf *big.Float
+		Expect(Merge(&bf, 1.5, WithStdConverters)).To(BeNil())
+		Expect(bf.Cmp(big.NewFloat(1.5))).To(Equal(0))
+
+		var bfv big.Float
+		Expect(Merge(&bfv, "2.5", WithStdConverters)).To(BeNil())
+		Expect(bfv.Cmp(big.NewFloat(2.5))).To(Equal(0))
+
+		var u *url.URL
+		Expect(Merge(&u, "http://x", WithStdConverters)).To(BeNil())
+		Expect(u.Host).To(Equal("x"))
+
+		var re *regexp.Regexp
+		Expect(Merge(&re, "(", WithStdConverters)).NotTo(BeNil())
+		Expect(re == nil).To(BeTrue())
+	})
+
+	It("merges into big values in struct", func() {
+		type budget struct {
+			Total big.Int
+			Rate  big.Float
+		}
+		dst := budget{}
+		err := Merge(&dst, map[string]interface{}{"Total": "123456789012345678901234567890", "Rate": 0.5}, WithStdConverters)
+		Expect(err).To(BeNil())
+		Expect(dst.Total.String()).To(Equal("123456789012345678901234567890"))
+		Expect(dst.Rate.Cmp(big.NewFloat(0.5))).To(Equal(0))
+
+		err = Merge(&dst, map[string]interface{}{"Total": 1.5}, WithStdConverters)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("merges config values", func() {
+		type config struct {
+			Listen  net.IP
+			Allow   *net.IPNet
+			Server  *url.URL
+			Pattern *regexp.Regexp
+			Secret  []byte
+			Limit   *big.Int
+		}
+		dst := config{}
+		err := Merge(&dst, map[string]interface{}{
+			"Listen":  "127.0.0.1",
+			"Allow":   "10.0.0.0/8",
+			"Server":  "http://localhost:8080",
+			"Pattern": "^v[0-9]+$",
+			"Secret":  "c2VjcmV0",
+			"Limit":   json.Number("18446744073709551616"),
+		}, WithStdConverters)
+		Expect(err).To(BeNil())
+		Expect(dst.Listen.String()).To(Equal("127.0.0.1"))
+		Expect(dst.Allow.String()).To(Equal("10.0.0.0/8"))
+		Expect(dst.Server.Host).To(Equal("localhost:8080"))
+		Expect(dst.Pattern.MatchString("v1")).To(BeTrue())
+		Expect(dst.Secret).To(Equal([]byte("secret")))
+		Expect(dst.Limit.String()).To(Equal("18446744073709551616"))
+	})
+})
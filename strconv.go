@@ -0,0 +1,141 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	strConvertion = reflect.ValueOf(func(dst, src interface{}, o *Options) (interface{}, error) {
+		dstV := reflect.ValueOf(dst)
+		srcV := reflect.ValueOf(src)
+		if !o.canOverwrite(dstV, srcV) {
+			return dst, nil
+		}
+		converted := reflect.New(dstV.Type()).Elem()
+		if srcV.Kind() == reflect.String {
+			if err := parseString(converted, srcV.String()); err != nil {
+				return dst, err
+			}
+			return converted.Interface(), nil
+		}
+		converted.SetString(formatString(srcV))
+		return converted.Interface(), nil
+	})
+)
+
+// integerBase returns the digits and base of the integer string s, it is
+// decimal unless s has an explicit "0x" prefix, so the leading zeros like
+// "0080" are not treated as octal.
+func integerBase(s string) (string, int) {
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return sign + s[2:], 16
+	}
+	return sign + s, 10
+}
+
+// parseString parses s into dst according to the kind of dst
+func parseString(dst reflect.Value, s string) error {
+	s = strings.TrimSpace(s)
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		digits, base := integerBase(s)
+		i, err := strconv.ParseInt(digits, base, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		digits, base := integerBase(s)
+		u, err := strconv.ParseUint(digits, base, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	case reflect.Bool:
+		b, err := parseBool(s)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	default:
+		return fmt.Errorf("can not parse string to %v", dst.Type())
+	}
+	return nil
+}
+
+// parseBool accepts the values of strconv.ParseBool and yes/no, y/n, on/off
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+// formatString formats the number or bool to string
+func formatString(src reflect.Value) string {
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(src.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(src.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(src.Float(), 'g', -1, src.Type().Bits())
+	case reflect.Bool:
+		return strconv.FormatBool(src.Bool())
+	}
+	return ""
+}
+
+// strConvertible reports whether strConvertion can convert src to dst
+func strConvertible(dst, src reflect.Type) bool {
+	switch src.Kind() {
+	case reflect.String:
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return true
+		case reflect.Float32, reflect.Float64:
+			return true
+		case reflect.Bool:
+			return true
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return dst.Kind() == reflect.String
+	case reflect.Float32, reflect.Float64:
+		return dst.Kind() == reflect.String
+	case reflect.Bool:
+		return dst.Kind() == reflect.String
+	}
+	return false
+}
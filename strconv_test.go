@@ -0,0 +1,66 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+var _ = Describe("str convertion", func() {
+	type port int
+
+	BeforeEach(func() {
+		opts.StrConvertion = true
+	})
+
+	DescribeTable(
+		"",
+		expectConvert,
+		Entry("string to int", 0, "8080", 8080, false),
+		Entry("string to named int", port(0), " 8080 ", port(8080), false),
+		Entry("hex string to int", 0, "0x10", 16, false),
+		Entry("negative hex string to int", 0, "-0X10", -16, false),
+		Entry("leading zero is decimal", 0, "010", 10, false),
+		Entry("leading zeros with 8", 0, "08080", 8080, false),
+		Entry("leading zeros to uint", uint16(0), "0080", uint16(80), false),
+		Entry("octal prefix is invalid", 0, "0o10", 0, true),
+		Entry("string to int8 overflow", int8(0), "300", int8(0), true),
+		Entry("string to uint", uint(0), "1", uint(1), false),
+		Entry("negative string to uint", uint(0), "-1", uint(0), true),
+		Entry("string to float", 0.0, "1.5", 1.5, false),
+		Entry("string to bool", false, "true", true, false),
+		Entry("yes to bool", false, "Yes", true, false),
+		Entry("on to bool", false, "on", true, false),
+		Entry("off to bool", true, "off", false, false),
+		Entry("invalid bool", false, "maybe", false, true),
+		Entry("invalid int", 0, "abc", 0, true),
+		Entry("int to string", "", 8080, "8080", false),
+		Entry("uint to string", "", uint8(1), "1", false),
+		Entry("float to string", "", 1.5, "1.5", false),
+		Entry("bool to string", "", true, "true", false),
+	)
+
+	It("is disabled by default", func() {
+		dst := 0
+		Expect(Merge(&dst, "1")).NotTo(BeNil())
+		Expect(Merge(&dst, "1", WithStrConvertion)).To(BeNil())
+		Expect(dst).To(Equal(1))
+	})
+
+	It("map values", func() {
+		dst := map[string]interface{}{"port": 80, "debug": false}
+		err := Merge(&dst, map[string]interface{}{"port": "8080", "debug": "yes"}, WithStrConvertion)
+		Expect(err).To(BeNil())
+		Expect(dst).To(Equal(map[string]interface{}{"port": 8080, "debug": true}))
+	})
+})
@@ -0,0 +1,100 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+)
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+	textConvertion = reflect.ValueOf(func(dst, src interface{}, o *Options) (interface{}, error) {
+		dstV := reflect.ValueOf(dst)
+		srcV := reflect.ValueOf(src)
+		if !o.canOverwrite(dstV, srcV) {
+			return dst, nil
+		}
+		if isText(srcV.Type()) && reflect.PtrTo(dstV.Type()).Implements(textUnmarshalerType) {
+			var text []byte
+			if srcV.Kind() == reflect.String {
+				text = []byte(srcV.String())
+			} else {
+				text = srcV.Bytes()
+			}
+			converted := reflect.New(dstV.Type())
+			if err := converted.Interface().(encoding.TextUnmarshaler).UnmarshalText(text); err != nil {
+				return dst, err
+			}
+			return converted.Elem().Interface(), nil
+		}
+
+		marshaler, ok := textMarshaler(srcV)
+		if !ok {
+			return dst, fmt.Errorf("can not convert %v to %v", srcV.Type(), dstV.Type())
+		}
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			return dst, err
+		}
+		converted := reflect.New(dstV.Type()).Elem()
+		converted.SetString(string(text))
+		return converted.Interface(), nil
+	})
+)
+
+// textMarshaler returns the encoding.TextMarshaler implemented by v or *v
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if v.Type().Implements(textMarshalerType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, false
+		}
+		return v.Interface().(encoding.TextMarshaler), true
+	}
+	if reflect.PtrTo(v.Type()).Implements(textMarshalerType) {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return ptr.Interface().(encoding.TextMarshaler), true
+	}
+	return nil, false
+}
+
+// isText reports whether t is string or []byte
+func isText(t reflect.Type) bool {
+	return t.Kind() == reflect.String || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8)
+}
+
+// textConvertible reports whether textConvertion can convert src to dst,
+// dst must implement encoding.TextUnmarshaler if src is string or []byte,
+// or src must implement encoding.TextMarshaler if dst is string. The byte
+// slices are not converted as text, e.g. []byte{10, 0, 0, 1} to net.IP is
+// converted element by element instead.
+func textConvertible(dst, src reflect.Type) bool {
+	if isBytes(dst) && isBytes(src) {
+		return false
+	}
+	if isText(src) && reflect.PtrTo(dst).Implements(textUnmarshalerType) {
+		return true
+	}
+	if dst.Kind() == reflect.String {
+		return src.Implements(textMarshalerType) || reflect.PtrTo(src).Implements(textMarshalerType)
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+)
+
+type testColor int
+
+func (c *testColor) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "red":
+		*c = 1
+	case "blue":
+		*c = 2
+	default:
+		return errors.New("unknown color")
+	}
+	return nil
+}
+
+func (c testColor) MarshalText() ([]byte, error) {
+	switch c {
+	case 1:
+		return []byte("red"), nil
+	case 2:
+		return []byte("blue"), nil
+	}
+	return nil, errors.New("unknown color")
+}
+
+var _ = Describe("text convertion", func() {
+	DescribeTable(
+		"",
+		expectConvert,
+		Entry("string to net.IP", net.IP{}, "127.0.0.1", net.ParseIP("127.0.0.1"), false),
+		Entry("raw bytes to net.IP", net.IP(nil), []byte{10, 0, 0, 1}, net.IP{10, 0, 0, 1}, false),
+		Entry("invalid string to net.IP", net.IP{}, "localhost", net.IP{}, true),
+		Entry("[]byte to *big.Int", (*big.Int)(nil), []byte("12345678901234567890"), func() *big.Int {
+			i, _ := new(big.Int).SetString("12345678901234567890", 10)
+			return i
+		}(), false),
+		Entry("string to enum", testColor(0), "Blue", testColor(2), false),
+		Entry("invalid string to enum", testColor(0), "green", testColor(0), true),
+		Entry("enum to string", "", testColor(1), "red", false),
+		Entry("net.IP to string", "", net.ParseIP("::1"), "::1", false),
+	)
+
+	It("is disabled", func() {
+		dst := testColor(0)
+		Expect(Merge(&dst, "red", WithoutTextConvertion)).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,140 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+
+	timeConvertion = reflect.ValueOf(func(dst, src interface{}, o *Options) (interface{}, error) {
+		dstV := reflect.ValueOf(dst)
+		srcV := reflect.ValueOf(src)
+		if !o.canOverwrite(dstV, srcV) {
+			return dst, nil
+		}
+		switch {
+		case dstV.Type() == durationType:
+			return toDuration(srcV, o)
+		case dstV.Type() == timeType:
+			return toTime(srcV, o)
+		case srcV.Type() == durationType:
+			converted := reflect.New(dstV.Type()).Elem()
+			converted.SetString(time.Duration(srcV.Int()).String())
+			return converted.Interface(), nil
+		case srcV.Type() == timeType:
+			converted := reflect.New(dstV.Type()).Elem()
+			converted.SetString(srcV.Interface().(time.Time).Format(o.TimeLayout))
+			return converted.Interface(), nil
+		}
+		return dst, fmt.Errorf("can not convert %v to %v", srcV.Type(), dstV.Type())
+	})
+)
+
+// toDuration converts string like "5s" or number in the unit of
+// o.DurationUnit to time.Duration
+func toDuration(src reflect.Value, o *Options) (time.Duration, error) {
+	switch src.Kind() {
+	case reflect.String:
+		s := strings.TrimSpace(src.String())
+		d, err := time.ParseDuration(s)
+		if err == nil {
+			return d, nil
+		}
+		// a number without unit
+		f, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return 0, err
+		}
+		return scaleDuration(big.NewFloat(f), o)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return scaleDuration(new(big.Float).SetInt64(src.Int()), o)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return scaleDuration(new(big.Float).SetUint64(src.Uint()), o)
+	case reflect.Float32, reflect.Float64:
+		f := src.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, fmt.Errorf("can not convert %v to %v", f, durationType)
+		}
+		return scaleDuration(big.NewFloat(f), o)
+	}
+	return 0, fmt.Errorf("can not convert %v to %v", src.Type(), durationType)
+}
+
+var (
+	minDuration = new(big.Float).SetInt64(math.MinInt64)
+	maxDuration = new(big.Float).SetInt64(math.MaxInt64)
+)
+
+// scaleDuration returns n in the unit of o.DurationUnit as time.Duration, the
+// overflow is checked like numeric conversions, see Options.StrictNumeric. The
+// overflowed duration is clamped.
+func scaleDuration(n *big.Float, o *Options) (time.Duration, error) {
+	ns := new(big.Float).SetPrec(128).Mul(n, new(big.Float).SetInt64(int64(o.DurationUnit)))
+	d, _ := ns.Int64()
+	if ns.Cmp(minDuration) < 0 || ns.Cmp(maxDuration) > 0 {
+		if err := o.checkLoss(fmt.Sprintf("%v in %v overflows %v", n, o.DurationUnit, durationType)); err != nil {
+			return 0, err
+		}
+	}
+	return time.Duration(d), nil
+}
+
+// toTime converts string in the layout of o.TimeLayout or Unix timestamp
+// in seconds to time.Time, the timestamp is converted to UTC time
+func toTime(src reflect.Value, o *Options) (time.Time, error) {
+	switch src.Kind() {
+	case reflect.String:
+		return time.Parse(o.TimeLayout, strings.TrimSpace(src.String()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return time.Unix(src.Int(), 0).UTC(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return time.Unix(int64(src.Uint()), 0).UTC(), nil
+	case reflect.Float32, reflect.Float64:
+		sec := src.Float()
+		return time.Unix(0, int64(sec*float64(time.Second))).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("can not convert %v to %v", src.Type(), timeType)
+}
+
+// timeConvertible reports whether timeConvertion can convert src to dst
+func timeConvertible(dst, src reflect.Type) bool {
+	if dst == durationType || dst == timeType {
+		switch src.Kind() {
+		case reflect.String:
+			return true
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return true
+		case reflect.Float32, reflect.Float64:
+			return true
+		}
+		return false
+	}
+	if src == durationType || src == timeType {
+		return dst.Kind() == reflect.String
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2019 zoumo(jim.zoumo@gmail.com). All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomerge
+
+import (
+	"math"
+	"time"
+)
+
+var _ = Describe("time convertion", func() {
+	DescribeTable(
+		"",
+		expectConvertWith,
+		Entry("string to duration", time.Duration(0), "5s", 5*time.Second, nil, false),
+		Entry("invalid string to duration", time.Duration(0), "5x", time.Duration(0), nil, true),
+		Entry("int to duration", time.Duration(0), 5, time.Duration(5), nil, false),
+		Entry("int to duration with unit", time.Duration(0), 5, 5*time.Second, WithDurationUnit(time.Second), false),
+		Entry("number string to duration with unit", time.Duration(0), "5", 5*time.Millisecond, WithDurationUnit(time.Millisecond), false),
+		Entry("float to duration with unit", time.Duration(0), 1.5, 1500*time.Millisecond, WithDurationUnit(time.Second), false),
+		Entry("duration to string", "", 5*time.Second, "5s", nil, false),
+		Entry("RFC3339 string to time", time.Time{}, "2020-01-02T03:04:05Z", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), nil, false),
+		Entry("string to time with layout", time.Time{}, "2020-01-02", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), WithTimeLayout("2006-01-02"), false),
+		Entry("invalid string to time", time.Time{}, "2020", time.Time{}, nil, true),
+		Entry("unix timestamp to time", time.Time{}, int64(1577934245), time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), nil, false),
+		Entry("float unix timestamp to time", time.Time{}, 1577934245.5, time.Date(2020, 1, 2, 3, 4, 5, 5e8, time.UTC), nil, false),
+		Entry("time to string", "", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), "2020-01-02T03:04:05Z", nil, false),
+		Entry("disabled", time.Duration(0), "5s", time.Duration(0), WithoutTimeConvertion, true),
+		Entry("overflowed int to duration", time.Duration(0), math.MaxInt64, time.Duration(0), strictDuration(time.Hour), true),
+		Entry("overflowed uint to duration", time.Duration(0), uint64(math.MaxUint64), time.Duration(0), strictDuration(time.Nanosecond), true),
+		Entry("overflowed float to duration", time.Duration(0), 1e300, time.Duration(0), strictDuration(time.Second), true),
+		Entry("overflowed number string to duration", time.Duration(0), "1e12", time.Duration(0), strictDuration(time.Hour), true),
+		Entry("NaN to duration", time.Duration(0), math.NaN(), time.Duration(0), nil, true),
+		Entry("min int to duration", time.Duration(0), int64(math.MinInt64), time.Duration(math.MinInt64), strictDuration(time.Nanosecond), false),
+	)
+
+	It("reports overflowed duration when not strict", func() {
+		type config struct {
+			Timeout time.Duration
+		}
+		dst := config{}
+		report := &Report{}
+		err := Merge(&dst, map[string]interface{}{"Timeout": math.MaxInt64}, WithDurationUnit(time.Hour), WithReport(report))
+		Expect(err).To(BeNil())
+		Expect(dst.Timeout).To(Equal(time.Duration(math.MaxInt64)))
+		entries := report.Entries()
+		Expect(len(entries)).To(Equal(1))
+		Expect(entries[0].Path).To(Equal("Timeout"))
+	})
+
+	It("merge into struct", func() {
+		type config struct {
+			Timeout   *time.Duration
+			CreatedAt time.Time
+		}
+		dst := config{}
+		Expect(Merge(&dst.Timeout, "5s")).To(BeNil())
+		Expect(*dst.Timeout).To(Equal(5 * time.Second))
+		Expect(Merge(&dst.CreatedAt, 1577934245)).To(BeNil())
+		Expect(dst.CreatedAt).To(Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+	})
+})
+
+func strictDuration(unit time.Duration) func(*Options) {
+	return func(o *Options) {
+		WithDurationUnit(unit)(o)
+		WithStrictNumeric(o)
+	}
+}